@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+)
+
+var activationCommands = []command{
+	{"list", "list the activations of a license", listActivations},
+	{"remove", "remove an activation, freeing its seat", removeActivation},
+}
+
+func listActivations(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave activation list", flag.ContinueOnError)
+	conn := newAdminFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	licenseID, err := idArg(fs)
+	if err != nil {
+		return err
+	}
+
+	a, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	activations, err := a.ListActivations(ctx, licenseID)
+	if err != nil {
+		return err
+	}
+	return conn.print(activations, func(w io.Writer) {
+		activationTable(w, activations)
+	})
+}
+
+func removeActivation(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave activation remove", flag.ContinueOnError)
+	conn := newAdminFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	id, err := idArg(fs)
+	if err != nil {
+		return err
+	}
+
+	a, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if err := a.RemoveActivation(ctx, id); err != nil {
+		return err
+	}
+	fmt.Printf("activation %d removed\n", id)
+	return nil
+}
+
+func activationTable(w io.Writer, activations []dto.ActivationResponse) {
+	fmt.Fprintln(w, "ID\tDEVICE\tMODE\tBOUND\tLAST CHECK-IN\tCREATED")
+	for _, a := range activations {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\t%s\n",
+			a.ID, a.DeviceID, a.Mode, a.DeviceBound, formatTime(a.LastCheckIn), a.CreatedAt.Format(time.DateTime))
+	}
+}
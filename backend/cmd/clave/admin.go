@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/services"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// admin is what the administrative commands need, served either by the
+// services on a direct database connection or by a server's admin API.
+type admin interface {
+	CreateProduct(ctx context.Context, data dto.ProductCreationRequest) (dto.ProductResponse, error)
+	ListProducts(ctx context.Context) ([]dto.ProductResponse, error)
+
+	CreateLicense(ctx context.Context, data dto.LicenseCreationRequest) (dto.LicenseCreationResponse, error)
+	CreateSignedLicense(ctx context.Context, data dto.SignedLicenseCreationRequest) (dto.LicenseCreationResponse, error)
+	ListLicenses(ctx context.Context, productID, limit int32) ([]dto.LicenseResponse, error)
+	GetLicense(ctx context.Context, id int32) (dto.LicenseDetailResponse, error)
+	RevokeLicense(ctx context.Context, id int32) error
+
+	ListActivations(ctx context.Context, licenseID int32) ([]dto.ActivationResponse, error)
+	RemoveActivation(ctx context.Context, id int32) error
+
+	Close()
+}
+
+// adminFlags are the connection flags every administrative command takes.
+type adminFlags struct {
+	server      *string
+	apiKey      *string
+	databaseURL *string
+	json        *bool
+}
+
+func newAdminFlags(fs *flag.FlagSet) adminFlags {
+	return adminFlags{
+		server:      fs.String("server", os.Getenv("CLAVE_SERVER"), "server URL; talk to its admin API instead of the database"),
+		apiKey:      fs.String("api-key", os.Getenv("CLAVE_ADMIN_API_KEY"), "admin API key for -server"),
+		databaseURL: fs.String("database-url", envOr("DATABASE_URL", defaultDatabaseURL), "postgres connection string"),
+		json:        fs.Bool("json", false, "print JSON instead of a table"),
+	}
+}
+
+// connect opens the admin API client if a server is given, or a database
+// connection otherwise. The latter reads signing keys and secrets from the
+// environment just like the server does.
+func (f adminFlags) connect(ctx context.Context) (admin, error) {
+	if *f.server != "" {
+		if *f.apiKey == "" {
+			return nil, errors.New("-api-key or CLAVE_ADMIN_API_KEY is required with -server")
+		}
+		return newRemoteAdmin(*f.server, *f.apiKey), nil
+	}
+
+	if err := services.CheckKeyConfig(); err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.New(ctx, *f.databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return localAdmin{pool: pool, svc: services.InitServices(db.New(pool), pool)}, nil
+}
+
+// print writes v as JSON with -json, or as a table through table otherwise.
+func (f adminFlags) print(v any, table func(w io.Writer)) error {
+	if *f.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// localAdmin calls the services directly.
+type localAdmin struct {
+	pool *pgxpool.Pool
+	svc  services.ServiceStack
+}
+
+func (a localAdmin) CreateProduct(ctx context.Context, data dto.ProductCreationRequest) (dto.ProductResponse, error) {
+	return a.svc.Product().CreateProduct(ctx, data)
+}
+
+func (a localAdmin) ListProducts(ctx context.Context) ([]dto.ProductResponse, error) {
+	return a.svc.Product().ListProducts(ctx)
+}
+
+func (a localAdmin) CreateLicense(ctx context.Context, data dto.LicenseCreationRequest) (dto.LicenseCreationResponse, error) {
+	return a.svc.License().NewLicense(ctx, data)
+}
+
+func (a localAdmin) CreateSignedLicense(ctx context.Context, data dto.SignedLicenseCreationRequest) (dto.LicenseCreationResponse, error) {
+	return a.svc.License().NewSignedLicense(ctx, data)
+}
+
+func (a localAdmin) ListLicenses(ctx context.Context, productID, limit int32) ([]dto.LicenseResponse, error) {
+	return a.svc.License().ListLicenses(ctx, productID, limit)
+}
+
+func (a localAdmin) GetLicense(ctx context.Context, id int32) (dto.LicenseDetailResponse, error) {
+	return a.svc.License().GetLicense(ctx, id)
+}
+
+func (a localAdmin) RevokeLicense(ctx context.Context, id int32) error {
+	return a.svc.Revocation().RevokeLicense(ctx, id)
+}
+
+func (a localAdmin) ListActivations(ctx context.Context, licenseID int32) ([]dto.ActivationResponse, error) {
+	return a.svc.License().ListActivations(ctx, licenseID)
+}
+
+func (a localAdmin) RemoveActivation(ctx context.Context, id int32) error {
+	return a.svc.Revocation().RevokeActivation(ctx, id)
+}
+
+func (a localAdmin) Close() { a.pool.Close() }
+
+// describe renders problems as their title and detail rather than JSON.
+func describe(err error) string {
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		return err.Error()
+	}
+
+	title, _ := p.Get("title")
+	detail, _ := p.Get("detail")
+	if detail != nil && detail != "" {
+		return fmt.Sprintf("%v: %v", title, detail)
+	}
+	return fmt.Sprint(title)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+)
+
+var keyCommands = []command{
+	{"generate", "print a new Ed25519 key pair and HMAC secret as environment variables", generateKeys},
+}
+
+// generateKeys prints the secrets the server reads, in the formats
+// services.InitServices expects, ready to be appended to an env file.
+func generateKeys(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave keys generate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+
+	b64 := base64.StdEncoding.EncodeToString
+	fmt.Printf("LICENSE_JWT_PRIVATE_KEY=%s\n", b64(priv))
+	fmt.Printf("# the public key for clients that pin it; checked against the private key on startup\n")
+	fmt.Printf("LICENSE_JWT_PUBLIC_KEY=%s\n", b64(pub))
+	fmt.Printf("LICENSE_HMAC_SECRET=%s\n", b64(secret))
+	return nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+)
+
+var licenseCommands = []command{
+	{"create", "create a license and print its key", createLicense},
+	{"list", "list the newest licenses", listLicenses},
+	{"show", "show a license and its activations", showLicense},
+	{"revoke", "revoke a license", revokeLicense},
+}
+
+func createLicense(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave license create", flag.ContinueOnError)
+	conn := newAdminFlags(fs)
+	productID := fs.Int("product", 0, "product id (required)")
+	maxActivations := fs.Int("max-activations", 1, "number of devices the license can activate")
+	signed := fs.Bool("signed", false, "issue a signed key that carries its own expiry and features")
+	expires := fs.String("expires", "", "expiry of a signed key, RFC 3339 or a duration such as 720h")
+	features := fs.String("features", "", "comma separated features of a signed key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *productID <= 0 {
+		return errors.New("-product is required")
+	}
+	if !*signed && (*expires != "" || *features != "") {
+		return errors.New("-expires and -features need -signed")
+	}
+
+	a, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	var created dto.LicenseCreationResponse
+	if *signed {
+		data := dto.SignedLicenseCreationRequest{
+			ProductID:      int32(*productID),
+			MaxActivations: int32(*maxActivations),
+		}
+		if *features != "" {
+			data.Features = strings.Split(*features, ",")
+		}
+		if *expires != "" {
+			t, err := parseExpiry(*expires)
+			if err != nil {
+				return err
+			}
+			data.ExpiresAt = &t
+		}
+		created, err = a.CreateSignedLicense(ctx, data)
+	} else {
+		created, err = a.CreateLicense(ctx, dto.LicenseCreationRequest{
+			ProductID:      int32(*productID),
+			MaxActivations: int32(*maxActivations),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	return conn.print(created, func(w io.Writer) {
+		fmt.Fprintln(w, created.LicenseKey)
+	})
+}
+
+// parseExpiry reads an absolute time or one relative to now.
+func parseExpiry(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(d).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -expires %q", s)
+	}
+	return t, nil
+}
+
+func listLicenses(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave license list", flag.ContinueOnError)
+	conn := newAdminFlags(fs)
+	productID := fs.Int("product", 0, "only licenses of this product")
+	limit := fs.Int("limit", 100, "number of licenses to list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	licenses, err := a.ListLicenses(ctx, int32(*productID), int32(*limit))
+	if err != nil {
+		return err
+	}
+	return conn.print(licenses, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tPRODUCT\tTYPE\tMAX\tACTIVE\tEXPIRES\tCREATED")
+		for _, l := range licenses {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%t\t%s\t%s\n",
+				l.ID, l.ProductID, l.KeyType, l.MaxActivations, l.Active, formatTime(l.ExpiresAt), l.CreatedAt.Format(time.DateTime))
+		}
+	})
+}
+
+func showLicense(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave license show", flag.ContinueOnError)
+	conn := newAdminFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	id, err := idArg(fs)
+	if err != nil {
+		return err
+	}
+
+	a, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	l, err := a.GetLicense(ctx, id)
+	if err != nil {
+		return err
+	}
+	return conn.print(l, func(w io.Writer) {
+		fmt.Fprintf(w, "id:\t%d\n", l.ID)
+		fmt.Fprintf(w, "product:\t%d\n", l.ProductID)
+		fmt.Fprintf(w, "type:\t%s\n", l.KeyType)
+		fmt.Fprintf(w, "active:\t%t\n", l.Active)
+		fmt.Fprintf(w, "activations:\t%d of %d\n", len(l.Activations), l.MaxActivations)
+		fmt.Fprintf(w, "features:\t%s\n", strings.Join(l.Features, ", "))
+		fmt.Fprintf(w, "expires:\t%s\n", formatTime(l.ExpiresAt))
+		fmt.Fprintf(w, "created:\t%s\n", l.CreatedAt.Format(time.DateTime))
+		if len(l.Activations) > 0 {
+			fmt.Fprintln(w)
+			activationTable(w, l.Activations)
+		}
+	})
+}
+
+func revokeLicense(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave license revoke", flag.ContinueOnError)
+	conn := newAdminFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	id, err := idArg(fs)
+	if err != nil {
+		return err
+	}
+
+	a, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if err := a.RevokeLicense(ctx, id); err != nil {
+		return err
+	}
+	fmt.Printf("license %d revoked\n", id)
+	return nil
+}
+
+// idArg reads the single positional id argument of a command.
+func idArg(fs *flag.FlagSet) (int32, error) {
+	if fs.NArg() != 1 {
+		return 0, fmt.Errorf("usage: %s [flags] <id>", fs.Name())
+	}
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid id %q", fs.Arg(0))
+	}
+	return int32(id), nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
@@ -1,37 +1,83 @@
+// Command clave runs the license server and administers it.
+//
+// Administrative commands work either directly against the database, with
+// the same environment as the server, or against a running server's admin
+// API when -server (or CLAVE_SERVER) is set.
 package main
 
 import (
 	"context"
-	"log"
-	"net/http"
-
-	"github.com/cheetahbyte/clave/internal/api"
-	"github.com/cheetahbyte/clave/internal/db"
-	"github.com/cheetahbyte/clave/internal/handlers"
-	"github.com/cheetahbyte/clave/internal/services"
-	"github.com/go-chi/chi/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
 )
 
-func main() {
-	r := chi.NewRouter()
+// command is a subcommand, or a group of them.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"serve", "run the license server", serve},
+	{"migrate", "apply, roll back and list database migrations", group("migrate", migrateCommands)},
+	{"keys", "generate signing keys and secrets", group("keys", keyCommands)},
+	{"product", "create and list products", group("product", productCommands)},
+	{"license", "create, inspect and revoke licenses", group("license", licenseCommands)},
+	{"activation", "list and remove activations", group("activation", activationCommands)},
+}
 
-	pool, err := pgxpool.New(context.Background(), "postgres://clave@localhost:54321/clave?sslmode=disable")
-	if err != nil {
-		log.Fatal(err)
+func main() {
+	// without arguments the binary behaves as it always has
+	args := os.Args[1:]
+	if len(args) == 0 {
+		args = []string{"serve"}
 	}
 
-	defer pool.Close()
+	if err := dispatch(context.Background(), "clave", commands, args); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "clave:", describe(err))
+		}
+		os.Exit(1)
+	}
+}
 
-	q := db.New(pool)
+// group runs one of a group's subcommands.
+func group(name string, subs []command) func(context.Context, []string) error {
+	return func(ctx context.Context, args []string) error {
+		return dispatch(ctx, "clave "+name, subs, args)
+	}
+}
 
-	svc := services.InitServices(q)
+func dispatch(ctx context.Context, prefix string, cmds []command, args []string) error {
+	if len(args) > 0 {
+		for _, c := range cmds {
+			if c.name == args[0] {
+				return c.run(ctx, args[1:])
+			}
+		}
+	}
 
-	h := handlers.New(svc)
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", prefix)
+	tw := tabwriter.NewWriter(os.Stderr, 0, 4, 2, ' ', 0)
+	for _, c := range cmds {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
 
-	api.Register(r, h)
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return flag.ErrHelp
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
 
-	if err := http.ListenAndServe(":8000", r); err != nil {
-		log.Fatal("failed to start server")
+// envOr returns the environment variable, or def if it is unset.
+func envOr(name, def string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
 	}
+	return def
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cheetahbyte/clave/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+var migrateCommands = []command{
+	{"up", "apply all pending migrations", migrateUp},
+	{"down", "roll back the latest migration", migrateDown},
+	{"status", "list migrations and whether they are applied", migrateStatus},
+}
+
+func migrateUp(ctx context.Context, args []string) error {
+	return withMigrations(ctx, "clave migrate up", args, func(p *goose.Provider) error {
+		results, err := p.Up(ctx)
+		for _, r := range results {
+			fmt.Printf("applied %s (%s)\n", r.Source.Path, r.Duration.Round(time.Millisecond))
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return nil
+	})
+}
+
+func migrateDown(ctx context.Context, args []string) error {
+	return withMigrations(ctx, "clave migrate down", args, func(p *goose.Provider) error {
+		r, err := p.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %s (%s)\n", r.Source.Path, r.Duration.Round(time.Millisecond))
+		return nil
+	})
+}
+
+func migrateStatus(ctx context.Context, args []string) error {
+	return withMigrations(ctx, "clave migrate status", args, func(p *goose.Provider) error {
+		statuses, err := p.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "MIGRATION\tSTATE\tAPPLIED")
+		for _, s := range statuses {
+			applied := "-"
+			if !s.AppliedAt.IsZero() {
+				applied = s.AppliedAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Source.Path, s.State, applied)
+		}
+		return tw.Flush()
+	})
+}
+
+// withMigrations runs fn with a provider for the embedded migrations on
+// the database given by the command's flags.
+func withMigrations(ctx context.Context, name string, args []string, fn func(p *goose.Provider) error) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	databaseURL := fs.String("database-url", envOr("DATABASE_URL", defaultDatabaseURL), "postgres connection string")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pool, err := pgxpool.New(ctx, *databaseURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	sqlDB := stdlib.OpenDBFromPool(pool)
+	defer sqlDB.Close()
+
+	p, err := migrations.NewProvider(sqlDB)
+	if err != nil {
+		return err
+	}
+	return fn(p)
+}
+
+// checkSchema makes sure the database is at the schema version the binary
+// was built for, migrating it first if autoMigrate is set.
+func checkSchema(ctx context.Context, pool *pgxpool.Pool, autoMigrate bool) error {
+	sqlDB := stdlib.OpenDBFromPool(pool)
+	defer sqlDB.Close()
+
+	p, err := migrations.NewProvider(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	if autoMigrate {
+		results, err := p.Up(ctx)
+		for _, r := range results {
+			slog.Info("applied migration", "source", r.Source.Path, "duration", r.Duration)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return migrations.Check(ctx, p)
+}
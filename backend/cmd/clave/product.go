@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+)
+
+var productCommands = []command{
+	{"create", "create a product", createProduct},
+	{"list", "list products", listProducts},
+}
+
+func createProduct(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave product create", flag.ContinueOnError)
+	conn := newAdminFlags(fs)
+	var data dto.ProductCreationRequest
+	fs.StringVar(&data.Name, "name", "", "product name (required)")
+	fs.StringVar(&data.Version, "version", "", "product version")
+	fs.StringVar(&data.KeyPrefix, "key-prefix", "", "license key prefix")
+	fs.StringVar(&data.TokenFormat, "token-format", "", "token format: jwt, paseto_v4 or cwt")
+	fs.StringVar(&data.SigningAlg, "signing-alg", "", "signing algorithm: EdDSA, ES256 or RS256")
+	fs.BoolVar(&data.RequireValidationNonce, "require-nonce", false, "reject validations without a nonce")
+	hwidThreshold := fs.Int("hwid-match-threshold", 0, "hardware components a drifted device must still share")
+	imported := fs.String("imported-key-prefixes", "", "comma separated prefixes of keys imported from other systems")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if data.Name == "" {
+		return errors.New("-name is required")
+	}
+	data.HwidMatchThreshold = int32(*hwidThreshold)
+	if *imported != "" {
+		data.ImportedKeyPrefixes = strings.Split(*imported, ",")
+	}
+
+	a, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	product, err := a.CreateProduct(ctx, data)
+	if err != nil {
+		return err
+	}
+	return conn.print(product, func(w io.Writer) {
+		productTable(w, []dto.ProductResponse{product})
+	})
+}
+
+func listProducts(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave product list", flag.ContinueOnError)
+	conn := newAdminFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	a, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	products, err := a.ListProducts(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.print(products, func(w io.Writer) {
+		productTable(w, products)
+	})
+}
+
+func productTable(w io.Writer, products []dto.ProductResponse) {
+	fmt.Fprintln(w, "ID\tNAME\tVERSION\tPREFIX\tFORMAT\tALG")
+	for _, p := range products {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", p.ID, p.Name, p.Version, p.KeyPrefix, p.TokenFormat, p.SigningAlg)
+	}
+}
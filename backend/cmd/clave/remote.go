@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	problem "github.com/cheetahbyte/problems"
+)
+
+// remoteAdmin calls a server's admin API.
+type remoteAdmin struct {
+	base   string
+	apiKey string
+	http   *http.Client
+}
+
+func newRemoteAdmin(server, apiKey string) remoteAdmin {
+	return remoteAdmin{
+		base:   strings.TrimSuffix(server, "/") + "/api/v1",
+		apiKey: apiKey,
+		http:   &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (a remoteAdmin) CreateProduct(ctx context.Context, data dto.ProductCreationRequest) (dto.ProductResponse, error) {
+	var out dto.ProductResponse
+	err := a.do(ctx, http.MethodPost, "/products", data, &out)
+	return out, err
+}
+
+func (a remoteAdmin) ListProducts(ctx context.Context) ([]dto.ProductResponse, error) {
+	var out []dto.ProductResponse
+	err := a.do(ctx, http.MethodGet, "/products", nil, &out)
+	return out, err
+}
+
+func (a remoteAdmin) CreateLicense(ctx context.Context, data dto.LicenseCreationRequest) (dto.LicenseCreationResponse, error) {
+	var out dto.LicenseCreationResponse
+	err := a.do(ctx, http.MethodPost, "/licenses", data, &out)
+	return out, err
+}
+
+func (a remoteAdmin) CreateSignedLicense(ctx context.Context, data dto.SignedLicenseCreationRequest) (dto.LicenseCreationResponse, error) {
+	var out dto.LicenseCreationResponse
+	err := a.do(ctx, http.MethodPost, "/signed", data, &out)
+	return out, err
+}
+
+func (a remoteAdmin) ListLicenses(ctx context.Context, productID, limit int32) ([]dto.LicenseResponse, error) {
+	q := url.Values{}
+	if productID != 0 {
+		q.Set("productId", strconv.Itoa(int(productID)))
+	}
+	if limit != 0 {
+		q.Set("limit", strconv.Itoa(int(limit)))
+	}
+
+	var out []dto.LicenseResponse
+	err := a.do(ctx, http.MethodGet, "/licenses?"+q.Encode(), nil, &out)
+	return out, err
+}
+
+func (a remoteAdmin) GetLicense(ctx context.Context, id int32) (dto.LicenseDetailResponse, error) {
+	var out dto.LicenseDetailResponse
+	err := a.do(ctx, http.MethodGet, "/licenses/"+strconv.Itoa(int(id)), nil, &out)
+	return out, err
+}
+
+func (a remoteAdmin) RevokeLicense(ctx context.Context, id int32) error {
+	return a.do(ctx, http.MethodPost, "/licenses/"+strconv.Itoa(int(id))+"/revoke", nil, nil)
+}
+
+func (a remoteAdmin) ListActivations(ctx context.Context, licenseID int32) ([]dto.ActivationResponse, error) {
+	var out []dto.ActivationResponse
+	err := a.do(ctx, http.MethodGet, "/licenses/"+strconv.Itoa(int(licenseID))+"/activations", nil, &out)
+	return out, err
+}
+
+func (a remoteAdmin) RemoveActivation(ctx context.Context, id int32) error {
+	return a.do(ctx, http.MethodDelete, "/activations/"+strconv.Itoa(int(id)), nil, nil)
+}
+
+func (a remoteAdmin) Close() {}
+
+// do sends body as JSON and decodes the response into out. Error responses
+// come back as the server's problem.
+func (a remoteAdmin) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.base+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		p := problem.Of(resp.StatusCode)
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		if err := p.UnmarshalJSON(raw); err != nil || p.Data()["title"] == nil {
+			p = problem.Of(resp.StatusCode).Append(problem.Title(resp.Status))
+		}
+		return p
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/api"
+	"github.com/cheetahbyte/clave/internal/handlers"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/services"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/go-chi/chi/v5"
+)
+
+// Every remote admin call has to reach a route behind the admin API key; a
+// wrong key is then refused before any handler runs.
+func TestRemoteAdminUsesAdminRoutes(t *testing.T) {
+	t.Setenv("CLAVE_ADMIN_API_KEY", "secret")
+	r := chi.NewRouter()
+	api.Register(r, handlers.New(services.InitServices(nil, nil)))
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	a := newRemoteAdmin(srv.URL, "wrong")
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"CreateProduct": func() error { _, err := a.CreateProduct(ctx, dto.ProductCreationRequest{}); return err },
+		"ListProducts":  func() error { _, err := a.ListProducts(ctx); return err },
+		"CreateLicense": func() error { _, err := a.CreateLicense(ctx, dto.LicenseCreationRequest{}); return err },
+		"CreateSignedLicense": func() error {
+			_, err := a.CreateSignedLicense(ctx, dto.SignedLicenseCreationRequest{})
+			return err
+		},
+		"ListLicenses":     func() error { _, err := a.ListLicenses(ctx, 1, 10); return err },
+		"GetLicense":       func() error { _, err := a.GetLicense(ctx, 1); return err },
+		"RevokeLicense":    func() error { return a.RevokeLicense(ctx, 1) },
+		"ListActivations":  func() error { _, err := a.ListActivations(ctx, 1); return err },
+		"RemoveActivation": func() error { return a.RemoveActivation(ctx, 1) },
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			err := call()
+			var p *problem.Problem
+			if !errors.As(err, &p) {
+				t.Fatalf("%s() = %v, want a problem", name, err)
+			}
+			if status, _ := p.Get("status"); fmt.Sprint(status) != "401" {
+				t.Errorf("%s() status = %v, want 401", name, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/api"
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers"
+	"github.com/cheetahbyte/clave/internal/metrics"
+	"github.com/cheetahbyte/clave/internal/services"
+	"github.com/cheetahbyte/clave/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultDatabaseURL is the database of the development compose setup.
+const defaultDatabaseURL = "postgres://clave@localhost:54321/clave?sslmode=disable"
+
+func serve(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clave serve", flag.ContinueOnError)
+	addr := fs.String("addr", envOr("CLAVE_ADDR", ":8000"), "listen address")
+	databaseURL := fs.String("database-url", envOr("DATABASE_URL", defaultDatabaseURL), "postgres connection string")
+	autoMigrate := fs.Bool("auto-migrate", os.Getenv("CLAVE_AUTO_MIGRATE") == "true", "apply pending migrations instead of refusing to start")
+	readTimeout := fs.Duration("read-timeout", 15*time.Second, "maximum time to read a request")
+	// bulk creation and imports may run for up to five minutes
+	writeTimeout := fs.Duration("write-timeout", 6*time.Minute, "maximum time to write a response")
+	idleTimeout := fs.Duration("idle-timeout", 2*time.Minute, "how long idle keep-alive connections are kept")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 30*time.Second, "how long in-flight requests may take to finish on shutdown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := services.CheckKeyConfig(); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("failed to flush traces", "err", err)
+		}
+	}()
+
+	poolConfig, err := pgxpool.ParseConfig(*databaseURL)
+	if err != nil {
+		return err
+	}
+	poolConfig.ConnConfig.Tracer = tracing.PgxTracer()
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	if err := checkSchema(ctx, pool, *autoMigrate); err != nil {
+		return err
+	}
+
+	metrics.RegisterPool(pool)
+
+	q := db.New(pool)
+
+	svc := services.InitServices(q, pool)
+
+	h := handlers.New(svc)
+
+	r := chi.NewRouter()
+	api.Register(r, h)
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           r,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", *addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	// a second signal kills the process instead of waiting for the drain
+	stop()
+	slog.Info("shutting down, draining requests", "timeout", *shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("server stopped")
+	return nil
+}
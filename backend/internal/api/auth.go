@@ -0,0 +1,38 @@
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	problem "github.com/cheetahbyte/problems"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequireAPIKey guards admin routes with a static bearer key. An empty key
+// disables the routes entirely rather than leaving them open.
+func RequireAPIKey(key string) func(http.Handler) http.Handler {
+	want := sha256.Sum256([]byte(key))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			gotSum := sha256.Sum256([]byte(got))
+
+			if key == "" || !ok || subtle.ConstantTimeCompare(want[:], gotSum[:]) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="clave-admin"`)
+				problem.Of(http.StatusUnauthorized).
+					Append(problem.Type("https://api.yourapp.dev/problems/unauthorized")).
+					Append(problem.Title("Unauthorized")).
+					Append(problem.Detail("A valid admin API key is required")).
+					Append(problem.Instance(r.URL.Path)).
+					Append(problem.Ext("requestId", middleware.GetReqID(r.Context()))).
+					WriteTo(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
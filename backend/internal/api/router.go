@@ -1,23 +1,78 @@
 package api
 
 import (
+	"os"
 	"time"
 
 	"github.com/cheetahbyte/clave/internal/handlers"
+	"github.com/cheetahbyte/clave/internal/logging"
+	"github.com/cheetahbyte/clave/internal/metrics"
+	"github.com/cheetahbyte/clave/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 func Register(r *chi.Mux, h *handlers.Handlers) {
 	r.Use(middleware.RequestID)
+	r.Use(logging.Middleware)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(3 * time.Second))
+	r.Use(tracing.Middleware)
+	r.Use(metrics.Middleware)
+
+	adminAuth := RequireAPIKey(os.Getenv("CLAVE_ADMIN_API_KEY"))
+
+	r.Get("/healthz", h.Healthz)
+	r.Get("/readyz", h.Readyz)
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/.well-known/jwks.json", h.JWKS)
 
 	r.Route("/api", func(apiRouter chi.Router) {
 		apiRouter.Route("/v1", func(v1Router chi.Router) {
-			v1Router.Post("/activate", h.ActivateLicense)
-			v1Router.Post("/", h.CreateLicense)
-			v1Router.Post("/validate", h.ValidateLicense)
+			v1Router.Group(func(g chi.Router) {
+				g.Use(middleware.Timeout(3 * time.Second))
+
+				g.Post("/activate", h.ActivateLicense)
+				g.Post("/activate/offline", h.ActivateOffline)
+				g.Post("/", h.CreateLicense)
+				g.Post("/validate", h.ValidateLicense)
+				g.Post("/heartbeat", h.Heartbeat)
+				g.Post("/nonce", h.IssueNonce)
+				g.Get("/time", h.ServerTime)
+
+				g.Get("/revocations", h.RevocationList)
+				g.Get("/jwks", h.JWKS)
+			})
+
+			v1Router.Group(func(g chi.Router) {
+				g.Use(middleware.Timeout(3 * time.Second))
+				g.Use(adminAuth)
+
+				g.Post("/signed", h.CreateSignedLicense)
+
+				g.Post("/products", h.CreateProduct)
+				g.Get("/products", h.ListProducts)
+
+				g.Post("/licenses", h.CreateLicense)
+				g.Get("/licenses", h.ListLicenses)
+				g.Get("/licenses/{id}", h.GetLicense)
+				g.Get("/licenses/{id}/activations", h.ListActivations)
+				g.Post("/licenses/{id}/revoke", h.RevokeLicense)
+				g.Post("/licenses/{id}/file", h.ExportLicenseFile)
+				g.Delete("/activations/{id}", h.RemoveActivation)
+				g.Get("/activations/{id}/drift", h.ActivationDrift)
+
+				g.Post("/tokens/revoke", h.RevokeToken)
+				g.Post("/tokens/introspect", h.IntrospectToken)
+			})
+
+			// batch operations hash thousands of keys with Argon2
+			v1Router.Group(func(g chi.Router) {
+				g.Use(middleware.Timeout(5 * time.Minute))
+				g.Use(adminAuth)
+
+				g.Post("/bulk", h.BulkCreateLicenses)
+				g.Post("/import", h.ImportLicenses)
+			})
 		})
 	})
 }
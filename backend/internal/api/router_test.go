@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/handlers"
+	"github.com/cheetahbyte/clave/internal/logging"
+	"github.com/cheetahbyte/clave/internal/services"
+	"github.com/go-chi/chi/v5"
+)
+
+// publicRoutes are the only routes reachable without the admin API key. A
+// method of * stands for a handler mounted for every method.
+var publicRoutes = map[string]bool{
+	"GET /healthz":               true,
+	"GET /readyz":                true,
+	"* /metrics":                 true,
+	"GET /.well-known/jwks.json": true,
+
+	"POST /api/v1/":                 true,
+	"POST /api/v1/activate":         true,
+	"POST /api/v1/activate/offline": true,
+	"POST /api/v1/validate":         true,
+	"POST /api/v1/heartbeat":        true,
+	"POST /api/v1/nonce":            true,
+	"GET /api/v1/time":              true,
+	"GET /api/v1/revocations":       true,
+	"GET /api/v1/jwks":              true,
+}
+
+func newTestRouter(t *testing.T, apiKey string) *chi.Mux {
+	t.Helper()
+	t.Setenv("CLAVE_ADMIN_API_KEY", apiKey)
+
+	r := chi.NewRouter()
+	Register(r, handlers.New(services.InitServices(nil, nil)))
+	return r
+}
+
+func TestAdminRoutesRequireAPIKey(t *testing.T) {
+	r := newTestRouter(t, "secret")
+
+	var admin int
+	err := chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if publicRoutes[method+" "+route] || publicRoutes["* "+route] {
+			return nil
+		}
+		admin++
+
+		path := strings.ReplaceAll(route, "{id}", "1")
+		for _, auth := range []string{"", "Bearer wrong", "secret", "Basic secret"} {
+			req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s with Authorization %q = %d, want 401", method, route, auth, w.Code)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the batch endpoints run thousands of Argon2 hashes
+	for _, route := range []string{"POST /api/v1/bulk", "POST /api/v1/import"} {
+		if publicRoutes[route] {
+			t.Errorf("%s is public", route)
+		}
+	}
+	if admin == 0 {
+		t.Fatal("no admin routes registered")
+	}
+}
+
+func TestAdminRoutesDisabledWithoutAPIKey(t *testing.T) {
+	r := newTestRouter(t, "")
+
+	for _, auth := range []string{"", "Bearer "} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/bulk", strings.NewReader(`{"productId":1,"count":10000}`))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("POST /api/v1/bulk with Authorization %q = %d, want 401", auth, w.Code)
+		}
+	}
+}
+
+func TestMalformedBodiesAreRejected(t *testing.T) {
+	r := newTestRouter(t, "secret")
+
+	routes := []string{
+		"/api/v1/",
+		"/api/v1/licenses",
+		"/api/v1/activate",
+		"/api/v1/activate/offline",
+		"/api/v1/validate",
+		"/api/v1/heartbeat",
+	}
+	for _, route := range routes {
+		for _, body := range []string{"", "{", `{"unknown":1}`} {
+			req := httptest.NewRequest(http.MethodPost, route, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer secret")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("POST %s with body %q = %d, want 400", route, body, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+				t.Errorf("POST %s with body %q: Content-Type = %q, want a problem", route, body, ct)
+			}
+		}
+	}
+}
+
+func TestBulkRejectsUnknownFormat(t *testing.T) {
+	r := newTestRouter(t, "secret")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bulk?format=xml", strings.NewReader(`{"productId":1,"count":1}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("POST /api/v1/bulk?format=xml = %d, want 400", w.Code)
+	}
+}
+
+func TestProblemsCarryRequestID(t *testing.T) {
+	r := newTestRouter(t, "secret")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/activate", strings.NewReader("{"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	id := w.Header().Get(logging.RequestIDHeader)
+	if id == "" {
+		t.Fatalf("no %s header", logging.RequestIDHeader)
+	}
+	var body struct {
+		RequestID string `json:"requestId"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.RequestID != id {
+		t.Errorf("problem requestId = %q, want %q", body.RequestID, id)
+	}
+}
+
+func TestNonceEndpoint(t *testing.T) {
+	r := newTestRouter(t, "secret")
+
+	var nonces []string
+	for range 2 {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/nonce", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST /api/v1/nonce = %d, want 200", w.Code)
+		}
+		if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("Cache-Control = %q, want no-store", cc)
+		}
+
+		var body struct {
+			Nonce string `json:"nonce"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		nonces = append(nonces, body.Nonce)
+	}
+	if nonces[0] == "" || nonces[0] == nonces[1] {
+		t.Errorf("nonces = %q, want two distinct nonces", nonces)
+	}
+}
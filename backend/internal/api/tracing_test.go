@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers"
+	"github.com/cheetahbyte/clave/internal/services"
+	"github.com/cheetahbyte/clave/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakePostgres accepts connections and answers every simple protocol
+// query with an empty result, so queries run and are traced without a
+// database.
+func fakePostgres(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go servePostgres(conn)
+		}
+	}()
+	return "postgres://clave@" + ln.Addr().String() + "/clave?sslmode=disable"
+}
+
+func servePostgres(conn net.Conn) {
+	defer conn.Close()
+
+	be := pgproto3.NewBackend(conn, conn)
+	if _, err := be.ReceiveStartupMessage(); err != nil {
+		return
+	}
+	be.Send(&pgproto3.AuthenticationOk{})
+	be.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+	be.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+	be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if be.Flush() != nil {
+		return
+	}
+
+	for {
+		msg, err := be.Receive()
+		if err != nil {
+			return
+		}
+		switch msg.(type) {
+		case *pgproto3.Query:
+			be.Send(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 0")})
+			be.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			if be.Flush() != nil {
+				return
+			}
+		case *pgproto3.Terminate:
+			return
+		}
+	}
+}
+
+func TestRequestSpansAreNested(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("CLAVE_ADMIN_API_KEY", "secret")
+
+	exp := tracetest.NewInMemoryExporter()
+	tp, err := tracing.NewProvider(ctx, exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	cfg, err := pgxpool.ParseConfig(fakePostgres(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.Tracer = tracing.PgxTracer()
+	cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	r := chi.NewRouter()
+	Register(r, handlers.New(services.InitServices(db.New(pool), pool)))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/licenses", strings.NewReader(`{"productId":1,"maxActivations":1}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		body, _ := io.ReadAll(w.Body)
+		t.Fatalf("status = %d, want 404 for the missing product: %s", w.Code, body)
+	}
+
+	if err := tp.ForceFlush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	spans := exp.GetSpans()
+	byID := map[trace.SpanID]tracetest.SpanStub{}
+	for _, s := range spans {
+		byID[s.SpanContext.SpanID()] = s
+	}
+	find := func(match func(tracetest.SpanStub) bool) tracetest.SpanStub {
+		t.Helper()
+		for _, s := range spans {
+			if match(s) {
+				return s
+			}
+		}
+		t.Fatalf("span not found in %v", spans.Snapshots())
+		return tracetest.SpanStub{}
+	}
+	// within reports whether s is nested, at any depth, in ancestor.
+	within := func(s, ancestor tracetest.SpanStub) bool {
+		for s.Parent.IsValid() {
+			if s.Parent.SpanID() == ancestor.SpanContext.SpanID() {
+				return true
+			}
+			s = byID[s.Parent.SpanID()]
+		}
+		return false
+	}
+	requestID := func(s tracetest.SpanStub) string {
+		for _, a := range s.Attributes {
+			if a.Key == tracing.RequestIDKey {
+				return a.Value.AsString()
+			}
+		}
+		return ""
+	}
+
+	handler := find(func(s tracetest.SpanStub) bool { return s.Name == "POST /api/v1/licenses" })
+	service := find(func(s tracetest.SpanStub) bool { return s.Name == "LicenseService.NewLicense" })
+	query := find(func(s tracetest.SpanStub) bool { return s.Name == "GetOneById" })
+
+	if handler.SpanKind != trace.SpanKindServer {
+		t.Errorf("handler span kind = %v, want server", handler.SpanKind)
+	}
+	if !within(service, handler) {
+		t.Error("service span is not nested in the handler span")
+	}
+	if !within(query, service) {
+		t.Error("query span is not nested in the service span")
+	}
+
+	id := requestID(handler)
+	if id == "" {
+		t.Fatalf("handler span has no %s", tracing.RequestIDKey)
+	}
+	for _, s := range spans {
+		if got := requestID(s); got != id {
+			t.Errorf("%s: %s = %q, want %q", s.Name, tracing.RequestIDKey, got, id)
+		}
+	}
+}
@@ -12,16 +12,27 @@ import (
 )
 
 const activateLicense = `-- name: ActivateLicense :one
-insert into activations (license_id, hwid) values($1, $2) returning id
+insert into activations (license_id, hwid, mode, device_public_key, device_encryption_key, components) values($1, $2, $3, $4, $5, $6) returning id
 `
 
 type ActivateLicenseParams struct {
-	LicenseID pgtype.Int4 `json:"license_id"`
-	Hwid      string      `json:"hwid"`
+	LicenseID           pgtype.Int4    `json:"license_id"`
+	Hwid                string         `json:"hwid"`
+	Mode                ActivationMode `json:"mode"`
+	DevicePublicKey     []byte         `json:"device_public_key"`
+	DeviceEncryptionKey []byte         `json:"device_encryption_key"`
+	Components          []byte         `json:"components"`
 }
 
 func (q *Queries) ActivateLicense(ctx context.Context, arg ActivateLicenseParams) (int32, error) {
-	row := q.db.QueryRow(ctx, activateLicense, arg.LicenseID, arg.Hwid)
+	row := q.db.QueryRow(ctx, activateLicense,
+		arg.LicenseID,
+		arg.Hwid,
+		arg.Mode,
+		arg.DevicePublicKey,
+		arg.DeviceEncryptionKey,
+		arg.Components,
+	)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
@@ -38,8 +49,55 @@ func (q *Queries) CountActivations(ctx context.Context, licenseID pgtype.Int4) (
 	return count, err
 }
 
+const deleteActivation = `-- name: DeleteActivation :one
+delete from activations where id = $1 returning id, license_id, hwid, last_check_in, created_at, mode, device_public_key, device_encryption_key, components
+`
+
+func (q *Queries) DeleteActivation(ctx context.Context, id int32) (Activation, error) {
+	row := q.db.QueryRow(ctx, deleteActivation, id)
+	var i Activation
+	err := row.Scan(
+		&i.ID,
+		&i.LicenseID,
+		&i.Hwid,
+		&i.LastCheckIn,
+		&i.CreatedAt,
+		&i.Mode,
+		&i.DevicePublicKey,
+		&i.DeviceEncryptionKey,
+		&i.Components,
+	)
+	return i, err
+}
+
+const getActivationByHwid = `-- name: GetActivationByHwid :one
+select id, license_id, hwid, last_check_in, created_at, mode, device_public_key, device_encryption_key, components from activations where license_id = $1 and hwid = $2
+`
+
+type GetActivationByHwidParams struct {
+	LicenseID pgtype.Int4 `json:"license_id"`
+	Hwid      string      `json:"hwid"`
+}
+
+func (q *Queries) GetActivationByHwid(ctx context.Context, arg GetActivationByHwidParams) (Activation, error) {
+	row := q.db.QueryRow(ctx, getActivationByHwid, arg.LicenseID, arg.Hwid)
+	var i Activation
+	err := row.Scan(
+		&i.ID,
+		&i.LicenseID,
+		&i.Hwid,
+		&i.LastCheckIn,
+		&i.CreatedAt,
+		&i.Mode,
+		&i.DevicePublicKey,
+		&i.DeviceEncryptionKey,
+		&i.Components,
+	)
+	return i, err
+}
+
 const getActivationsForLicense = `-- name: GetActivationsForLicense :many
-select id, license_id, hwid, last_check_in, created_at from activations where license_id = $1
+select id, license_id, hwid, last_check_in, created_at, mode, device_public_key, device_encryption_key, components from activations where license_id = $1
 `
 
 func (q *Queries) GetActivationsForLicense(ctx context.Context, licenseID pgtype.Int4) ([]Activation, error) {
@@ -57,6 +115,10 @@ func (q *Queries) GetActivationsForLicense(ctx context.Context, licenseID pgtype
 			&i.Hwid,
 			&i.LastCheckIn,
 			&i.CreatedAt,
+			&i.Mode,
+			&i.DevicePublicKey,
+			&i.DeviceEncryptionKey,
+			&i.Components,
 		); err != nil {
 			return nil, err
 		}
@@ -67,3 +129,99 @@ func (q *Queries) GetActivationsForLicense(ctx context.Context, licenseID pgtype
 	}
 	return items, nil
 }
+
+const listActivationDrift = `-- name: ListActivationDrift :many
+select id, activation_id, old_hwid, new_hwid, matched, total, changed, created_at from activation_drift where activation_id = $1 order by id
+`
+
+func (q *Queries) ListActivationDrift(ctx context.Context, activationID int32) ([]ActivationDrift, error) {
+	rows, err := q.db.Query(ctx, listActivationDrift, activationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ActivationDrift{}
+	for rows.Next() {
+		var i ActivationDrift
+		if err := rows.Scan(
+			&i.ID,
+			&i.ActivationID,
+			&i.OldHwid,
+			&i.NewHwid,
+			&i.Matched,
+			&i.Total,
+			&i.Changed,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordActivationDrift = `-- name: RecordActivationDrift :exec
+insert into activation_drift (activation_id, old_hwid, new_hwid, matched, total, changed) values($1, $2, $3, $4, $5, $6)
+`
+
+type RecordActivationDriftParams struct {
+	ActivationID int32    `json:"activation_id"`
+	OldHwid      string   `json:"old_hwid"`
+	NewHwid      string   `json:"new_hwid"`
+	Matched      int32    `json:"matched"`
+	Total        int32    `json:"total"`
+	Changed      []string `json:"changed"`
+}
+
+func (q *Queries) RecordActivationDrift(ctx context.Context, arg RecordActivationDriftParams) error {
+	_, err := q.db.Exec(ctx, recordActivationDrift,
+		arg.ActivationID,
+		arg.OldHwid,
+		arg.NewHwid,
+		arg.Matched,
+		arg.Total,
+		arg.Changed,
+	)
+	return err
+}
+
+const touchActivation = `-- name: TouchActivation :exec
+update activations set last_check_in = now() where id = $1
+`
+
+func (q *Queries) TouchActivation(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, touchActivation, id)
+	return err
+}
+
+const updateActivationFingerprint = `-- name: UpdateActivationFingerprint :exec
+update activations set
+    hwid = $2,
+    components = $3,
+    device_public_key = coalesce($4, device_public_key),
+    device_encryption_key = coalesce($5, device_encryption_key),
+    last_check_in = now()
+where id = $1
+`
+
+type UpdateActivationFingerprintParams struct {
+	ID                  int32  `json:"id"`
+	Hwid                string `json:"hwid"`
+	Components          []byte `json:"components"`
+	DevicePublicKey     []byte `json:"device_public_key"`
+	DeviceEncryptionKey []byte `json:"device_encryption_key"`
+}
+
+func (q *Queries) UpdateActivationFingerprint(ctx context.Context, arg UpdateActivationFingerprintParams) error {
+	_, err := q.db.Exec(ctx, updateActivationFingerprint,
+		arg.ID,
+		arg.Hwid,
+		arg.Components,
+		arg.DevicePublicKey,
+		arg.DeviceEncryptionKey,
+	)
+	return err
+}
@@ -0,0 +1,49 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: batches.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createLicenseBatch = `-- name: CreateLicenseBatch :one
+insert into license_batches (product_id, size) values($1, $2) returning id, product_id, size, created_at
+`
+
+type CreateLicenseBatchParams struct {
+	ProductID pgtype.Int4 `json:"product_id"`
+	Size      int32       `json:"size"`
+}
+
+func (q *Queries) CreateLicenseBatch(ctx context.Context, arg CreateLicenseBatchParams) (LicenseBatch, error) {
+	row := q.db.QueryRow(ctx, createLicenseBatch, arg.ProductID, arg.Size)
+	var i LicenseBatch
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getLicenseBatchById = `-- name: GetLicenseBatchById :one
+select id, product_id, size, created_at from license_batches where id = $1
+`
+
+func (q *Queries) GetLicenseBatchById(ctx context.Context, id int32) (LicenseBatch, error) {
+	row := q.db.QueryRow(ctx, getLicenseBatchById, id)
+	var i LicenseBatch
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,46 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: copyfrom.go
+
+package db
+
+import (
+	"context"
+)
+
+// iteratorForCreateLicenses implements pgx.CopyFromSource.
+type iteratorForCreateLicenses struct {
+	rows                 []CreateLicensesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateLicenses) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateLicenses) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ProductID,
+		r.rows[0].MaxActivations,
+		r.rows[0].LookupDigest,
+		r.rows[0].KeyPhc,
+		r.rows[0].BatchID,
+	}, nil
+}
+
+func (r iteratorForCreateLicenses) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateLicenses(ctx context.Context, arg []CreateLicensesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"licenses"}, []string{"product_id", "max_activations", "lookup_digest", "key_phc", "batch_id"}, &iteratorForCreateLicenses{rows: arg})
+}
@@ -12,7 +12,7 @@ import (
 )
 
 const createLicense = `-- name: CreateLicense :one
-INSERT INTO licenses(product_id, max_activations, lookup_digest, key_phc) values($1, $2, $3, $4) returning id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc
+INSERT INTO licenses(product_id, max_activations, lookup_digest, key_phc) values($1, $2, $3, $4) returning id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc, batch_id, key_type, features, key_normalized
 `
 
 type CreateLicenseParams struct {
@@ -39,12 +39,88 @@ func (q *Queries) CreateLicense(ctx context.Context, arg CreateLicenseParams) (L
 		&i.CreatedAt,
 		&i.LookupDigest,
 		&i.KeyPhc,
+		&i.BatchID,
+		&i.KeyType,
+		&i.Features,
+		&i.KeyNormalized,
+	)
+	return i, err
+}
+
+type CreateLicensesParams struct {
+	ProductID      pgtype.Int4 `json:"product_id"`
+	MaxActivations pgtype.Int4 `json:"max_activations"`
+	LookupDigest   []byte      `json:"lookup_digest"`
+	KeyPhc         string      `json:"key_phc"`
+	BatchID        pgtype.Int4 `json:"batch_id"`
+}
+
+const createSignedLicense = `-- name: CreateSignedLicense :one
+INSERT INTO licenses(product_id, max_activations, expires_at, features, key_type, lookup_digest, key_phc) values($1, $2, $3, $4, 'signed', $5, $6) returning id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc, batch_id, key_type, features, key_normalized
+`
+
+type CreateSignedLicenseParams struct {
+	ProductID      pgtype.Int4        `json:"product_id"`
+	MaxActivations pgtype.Int4        `json:"max_activations"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	Features       []string           `json:"features"`
+	LookupDigest   []byte             `json:"lookup_digest"`
+	KeyPhc         string             `json:"key_phc"`
+}
+
+func (q *Queries) CreateSignedLicense(ctx context.Context, arg CreateSignedLicenseParams) (License, error) {
+	row := q.db.QueryRow(ctx, createSignedLicense,
+		arg.ProductID,
+		arg.MaxActivations,
+		arg.ExpiresAt,
+		arg.Features,
+		arg.LookupDigest,
+		arg.KeyPhc,
+	)
+	var i License
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.MaxActivations,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.LookupDigest,
+		&i.KeyPhc,
+		&i.BatchID,
+		&i.KeyType,
+		&i.Features,
+		&i.KeyNormalized,
+	)
+	return i, err
+}
+
+const deactivateLicense = `-- name: DeactivateLicense :one
+update licenses set is_active = false where id = $1 returning id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc, batch_id, key_type, features, key_normalized
+`
+
+func (q *Queries) DeactivateLicense(ctx context.Context, id int32) (License, error) {
+	row := q.db.QueryRow(ctx, deactivateLicense, id)
+	var i License
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.MaxActivations,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.LookupDigest,
+		&i.KeyPhc,
+		&i.BatchID,
+		&i.KeyType,
+		&i.Features,
+		&i.KeyNormalized,
 	)
 	return i, err
 }
 
 const getLicenseByDigest = `-- name: GetLicenseByDigest :one
-select id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc from licenses where lookup_digest = $1
+select id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc, batch_id, key_type, features, key_normalized from licenses where lookup_digest = $1
 `
 
 func (q *Queries) GetLicenseByDigest(ctx context.Context, lookupDigest []byte) (License, error) {
@@ -59,12 +135,16 @@ func (q *Queries) GetLicenseByDigest(ctx context.Context, lookupDigest []byte) (
 		&i.CreatedAt,
 		&i.LookupDigest,
 		&i.KeyPhc,
+		&i.BatchID,
+		&i.KeyType,
+		&i.Features,
+		&i.KeyNormalized,
 	)
 	return i, err
 }
 
 const getLicenseById = `-- name: GetLicenseById :one
-select id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc from licenses where id = $1
+select id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc, batch_id, key_type, features, key_normalized from licenses where id = $1
 `
 
 func (q *Queries) GetLicenseById(ctx context.Context, id int32) (License, error) {
@@ -79,6 +159,136 @@ func (q *Queries) GetLicenseById(ctx context.Context, id int32) (License, error)
 		&i.CreatedAt,
 		&i.LookupDigest,
 		&i.KeyPhc,
+		&i.BatchID,
+		&i.KeyType,
+		&i.Features,
+		&i.KeyNormalized,
+	)
+	return i, err
+}
+
+const getProductLicenseByDigest = `-- name: GetProductLicenseByDigest :one
+select id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc, batch_id, key_type, features, key_normalized from licenses where lookup_digest = $1 and product_id = $2
+`
+
+type GetProductLicenseByDigestParams struct {
+	LookupDigest []byte      `json:"lookup_digest"`
+	ProductID    pgtype.Int4 `json:"product_id"`
+}
+
+func (q *Queries) GetProductLicenseByDigest(ctx context.Context, arg GetProductLicenseByDigestParams) (License, error) {
+	row := q.db.QueryRow(ctx, getProductLicenseByDigest, arg.LookupDigest, arg.ProductID)
+	var i License
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.MaxActivations,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.LookupDigest,
+		&i.KeyPhc,
+		&i.BatchID,
+		&i.KeyType,
+		&i.Features,
+		&i.KeyNormalized,
 	)
 	return i, err
 }
+
+const importLicense = `-- name: ImportLicense :one
+INSERT INTO licenses(product_id, max_activations, expires_at, lookup_digest, key_phc) values($1, $2, $3, $4, $5) returning id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc, batch_id, key_type, features, key_normalized
+`
+
+type ImportLicenseParams struct {
+	ProductID      pgtype.Int4        `json:"product_id"`
+	MaxActivations pgtype.Int4        `json:"max_activations"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+	LookupDigest   []byte             `json:"lookup_digest"`
+	KeyPhc         string             `json:"key_phc"`
+}
+
+func (q *Queries) ImportLicense(ctx context.Context, arg ImportLicenseParams) (License, error) {
+	row := q.db.QueryRow(ctx, importLicense,
+		arg.ProductID,
+		arg.MaxActivations,
+		arg.ExpiresAt,
+		arg.LookupDigest,
+		arg.KeyPhc,
+	)
+	var i License
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.MaxActivations,
+		&i.IsActive,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.LookupDigest,
+		&i.KeyPhc,
+		&i.BatchID,
+		&i.KeyType,
+		&i.Features,
+		&i.KeyNormalized,
+	)
+	return i, err
+}
+
+const listLicenses = `-- name: ListLicenses :many
+select id, product_id, max_activations, is_active, expires_at, created_at, lookup_digest, key_phc, batch_id, key_type, features, key_normalized from licenses
+where $1::integer is null or product_id = $1::integer
+order by id desc
+limit $2
+`
+
+type ListLicensesParams struct {
+	ProductID pgtype.Int4 `json:"product_id"`
+	RowLimit  int32       `json:"row_limit"`
+}
+
+func (q *Queries) ListLicenses(ctx context.Context, arg ListLicensesParams) ([]License, error) {
+	rows, err := q.db.Query(ctx, listLicenses, arg.ProductID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []License{}
+	for rows.Next() {
+		var i License
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.MaxActivations,
+			&i.IsActive,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.LookupDigest,
+			&i.KeyPhc,
+			&i.BatchID,
+			&i.KeyType,
+			&i.Features,
+			&i.KeyNormalized,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateLicenseKeyHash = `-- name: UpdateLicenseKeyHash :exec
+update licenses set key_phc = $2, key_normalized = true where id = $1
+`
+
+type UpdateLicenseKeyHashParams struct {
+	ID     int32  `json:"id"`
+	KeyPhc string `json:"key_phc"`
+}
+
+func (q *Queries) UpdateLicenseKeyHash(ctx context.Context, arg UpdateLicenseKeyHashParams) error {
+	_, err := q.db.Exec(ctx, updateLicenseKeyHash, arg.ID, arg.KeyPhc)
+	return err
+}
@@ -5,15 +5,303 @@
 package db
 
 import (
+	"database/sql/driver"
+	"fmt"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ActivationMode string
+
+const (
+	ActivationModeOnline   ActivationMode = "online"
+	ActivationModeOffline  ActivationMode = "offline"
+	ActivationModeImported ActivationMode = "imported"
+)
+
+func (e *ActivationMode) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ActivationMode(s)
+	case string:
+		*e = ActivationMode(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ActivationMode: %T", src)
+	}
+	return nil
+}
+
+type NullActivationMode struct {
+	ActivationMode ActivationMode `json:"activation_mode"`
+	Valid          bool           `json:"valid"` // Valid is true if ActivationMode is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullActivationMode) Scan(value interface{}) error {
+	if value == nil {
+		ns.ActivationMode, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ActivationMode.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullActivationMode) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ActivationMode), nil
+}
+
+func (e ActivationMode) Valid() bool {
+	switch e {
+	case ActivationModeOnline,
+		ActivationModeOffline,
+		ActivationModeImported:
+		return true
+	}
+	return false
+}
+
+type LicenseKeyType string
+
+const (
+	LicenseKeyTypeRandom LicenseKeyType = "random"
+	LicenseKeyTypeSigned LicenseKeyType = "signed"
+)
+
+func (e *LicenseKeyType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LicenseKeyType(s)
+	case string:
+		*e = LicenseKeyType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LicenseKeyType: %T", src)
+	}
+	return nil
+}
+
+type NullLicenseKeyType struct {
+	LicenseKeyType LicenseKeyType `json:"license_key_type"`
+	Valid          bool           `json:"valid"` // Valid is true if LicenseKeyType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLicenseKeyType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LicenseKeyType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LicenseKeyType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLicenseKeyType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LicenseKeyType), nil
+}
+
+func (e LicenseKeyType) Valid() bool {
+	switch e {
+	case LicenseKeyTypeRandom,
+		LicenseKeyTypeSigned:
+		return true
+	}
+	return false
+}
+
+type RevocationKind string
+
+const (
+	RevocationKindLicense    RevocationKind = "license"
+	RevocationKindActivation RevocationKind = "activation"
+	RevocationKindToken      RevocationKind = "token"
+)
+
+func (e *RevocationKind) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RevocationKind(s)
+	case string:
+		*e = RevocationKind(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RevocationKind: %T", src)
+	}
+	return nil
+}
+
+type NullRevocationKind struct {
+	RevocationKind RevocationKind `json:"revocation_kind"`
+	Valid          bool           `json:"valid"` // Valid is true if RevocationKind is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRevocationKind) Scan(value interface{}) error {
+	if value == nil {
+		ns.RevocationKind, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RevocationKind.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRevocationKind) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RevocationKind), nil
+}
+
+func (e RevocationKind) Valid() bool {
+	switch e {
+	case RevocationKindLicense,
+		RevocationKindActivation,
+		RevocationKindToken:
+		return true
+	}
+	return false
+}
+
+type SigningAlg string
+
+const (
+	SigningAlgEdDSA SigningAlg = "EdDSA"
+	SigningAlgES256 SigningAlg = "ES256"
+	SigningAlgRS256 SigningAlg = "RS256"
+)
+
+func (e *SigningAlg) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SigningAlg(s)
+	case string:
+		*e = SigningAlg(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SigningAlg: %T", src)
+	}
+	return nil
+}
+
+type NullSigningAlg struct {
+	SigningAlg SigningAlg `json:"signing_alg"`
+	Valid      bool       `json:"valid"` // Valid is true if SigningAlg is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSigningAlg) Scan(value interface{}) error {
+	if value == nil {
+		ns.SigningAlg, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SigningAlg.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSigningAlg) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SigningAlg), nil
+}
+
+func (e SigningAlg) Valid() bool {
+	switch e {
+	case SigningAlgEdDSA,
+		SigningAlgES256,
+		SigningAlgRS256:
+		return true
+	}
+	return false
+}
+
+type TokenFormat string
+
+const (
+	TokenFormatJwt      TokenFormat = "jwt"
+	TokenFormatPasetoV4 TokenFormat = "paseto_v4"
+	TokenFormatCwt      TokenFormat = "cwt"
+)
+
+func (e *TokenFormat) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TokenFormat(s)
+	case string:
+		*e = TokenFormat(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TokenFormat: %T", src)
+	}
+	return nil
+}
+
+type NullTokenFormat struct {
+	TokenFormat TokenFormat `json:"token_format"`
+	Valid       bool        `json:"valid"` // Valid is true if TokenFormat is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTokenFormat) Scan(value interface{}) error {
+	if value == nil {
+		ns.TokenFormat, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TokenFormat.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTokenFormat) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TokenFormat), nil
+}
+
+func (e TokenFormat) Valid() bool {
+	switch e {
+	case TokenFormatJwt,
+		TokenFormatPasetoV4,
+		TokenFormatCwt:
+		return true
+	}
+	return false
+}
+
 type Activation struct {
-	ID          int32              `json:"id"`
-	LicenseID   pgtype.Int4        `json:"license_id"`
-	Hwid        string             `json:"hwid"`
-	LastCheckIn pgtype.Timestamptz `json:"last_check_in"`
-	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	ID                  int32              `json:"id"`
+	LicenseID           pgtype.Int4        `json:"license_id"`
+	Hwid                string             `json:"hwid"`
+	LastCheckIn         pgtype.Timestamptz `json:"last_check_in"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	Mode                ActivationMode     `json:"mode"`
+	DevicePublicKey     []byte             `json:"device_public_key"`
+	DeviceEncryptionKey []byte             `json:"device_encryption_key"`
+	Components          []byte             `json:"components"`
+}
+
+type ActivationDrift struct {
+	ID           int64              `json:"id"`
+	ActivationID int32              `json:"activation_id"`
+	OldHwid      string             `json:"old_hwid"`
+	NewHwid      string             `json:"new_hwid"`
+	Matched      int32              `json:"matched"`
+	Total        int32              `json:"total"`
+	Changed      []string           `json:"changed"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type IssuedToken struct {
+	Jti       string             `json:"jti"`
+	LicenseID int32              `json:"license_id"`
+	IssuedAt  pgtype.Timestamptz `json:"issued_at"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
 }
 
 type License struct {
@@ -25,11 +313,49 @@ type License struct {
 	CreatedAt      pgtype.Timestamptz `json:"created_at"`
 	LookupDigest   []byte             `json:"lookup_digest"`
 	KeyPhc         string             `json:"key_phc"`
+	BatchID        pgtype.Int4        `json:"batch_id"`
+	KeyType        LicenseKeyType     `json:"key_type"`
+	Features       []string           `json:"features"`
+	KeyNormalized  bool               `json:"key_normalized"`
 }
 
-type Product struct {
+type LicenseBatch struct {
 	ID        int32              `json:"id"`
-	Name      string             `json:"name"`
-	Version   pgtype.Text        `json:"version"`
+	ProductID pgtype.Int4        `json:"product_id"`
+	Size      int32              `json:"size"`
 	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
+
+type Product struct {
+	ID                     int32              `json:"id"`
+	Name                   string             `json:"name"`
+	Version                pgtype.Text        `json:"version"`
+	CreatedAt              pgtype.Timestamptz `json:"created_at"`
+	KeyPrefix              pgtype.Text        `json:"key_prefix"`
+	KeyBytes               int32              `json:"key_bytes"`
+	KeyGroupSize           int32              `json:"key_group_size"`
+	RequireValidationNonce bool               `json:"require_validation_nonce"`
+	TokenFormat            TokenFormat        `json:"token_format"`
+	SigningAlg             SigningAlg         `json:"signing_alg"`
+	TokenEncryptionKey     []byte             `json:"token_encryption_key"`
+	HwidMatchThreshold     int32              `json:"hwid_match_threshold"`
+	ImportedKeyPrefixes    []string           `json:"imported_key_prefixes"`
+}
+
+type Revocation struct {
+	ID        int64              `json:"id"`
+	Kind      RevocationKind     `json:"kind"`
+	Subject   string             `json:"subject"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+	Version   int64              `json:"version"`
+}
+
+type RevocationCounter struct {
+	ID      bool  `json:"id"`
+	Version int64 `json:"version"`
+}
+
+type UsedNonce struct {
+	Nonce     string             `json:"nonce"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
@@ -0,0 +1,39 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: nonces.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const consumeNonce = `-- name: ConsumeNonce :execrows
+insert into used_nonces (nonce, expires_at) values($1, $2)
+on conflict (nonce) do nothing
+`
+
+type ConsumeNonceParams struct {
+	Nonce     string             `json:"nonce"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) ConsumeNonce(ctx context.Context, arg ConsumeNonceParams) (int64, error) {
+	result, err := q.db.Exec(ctx, consumeNonce, arg.Nonce, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredNonces = `-- name: DeleteExpiredNonces :exec
+delete from used_nonces where expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredNonces(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredNonces)
+	return err
+}
@@ -7,10 +7,63 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const createProduct = `-- name: CreateProduct :one
+insert into products (name, version, key_prefix, key_bytes, key_group_size, require_validation_nonce, token_format, signing_alg, token_encryption_key, hwid_match_threshold, imported_key_prefixes) values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) returning id, name, version, created_at, key_prefix, key_bytes, key_group_size, require_validation_nonce, token_format, signing_alg, token_encryption_key, hwid_match_threshold, imported_key_prefixes
+`
+
+type CreateProductParams struct {
+	Name                   string      `json:"name"`
+	Version                pgtype.Text `json:"version"`
+	KeyPrefix              pgtype.Text `json:"key_prefix"`
+	KeyBytes               int32       `json:"key_bytes"`
+	KeyGroupSize           int32       `json:"key_group_size"`
+	RequireValidationNonce bool        `json:"require_validation_nonce"`
+	TokenFormat            TokenFormat `json:"token_format"`
+	SigningAlg             SigningAlg  `json:"signing_alg"`
+	TokenEncryptionKey     []byte      `json:"token_encryption_key"`
+	HwidMatchThreshold     int32       `json:"hwid_match_threshold"`
+	ImportedKeyPrefixes    []string    `json:"imported_key_prefixes"`
+}
+
+func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
+	row := q.db.QueryRow(ctx, createProduct,
+		arg.Name,
+		arg.Version,
+		arg.KeyPrefix,
+		arg.KeyBytes,
+		arg.KeyGroupSize,
+		arg.RequireValidationNonce,
+		arg.TokenFormat,
+		arg.SigningAlg,
+		arg.TokenEncryptionKey,
+		arg.HwidMatchThreshold,
+		arg.ImportedKeyPrefixes,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Version,
+		&i.CreatedAt,
+		&i.KeyPrefix,
+		&i.KeyBytes,
+		&i.KeyGroupSize,
+		&i.RequireValidationNonce,
+		&i.TokenFormat,
+		&i.SigningAlg,
+		&i.TokenEncryptionKey,
+		&i.HwidMatchThreshold,
+		&i.ImportedKeyPrefixes,
+	)
+	return i, err
+}
+
 const getOneById = `-- name: GetOneById :one
-select id, name, version, created_at from products where id = $1
+select id, name, version, created_at, key_prefix, key_bytes, key_group_size, require_validation_nonce, token_format, signing_alg, token_encryption_key, hwid_match_threshold, imported_key_prefixes from products where id = $1
 `
 
 func (q *Queries) GetOneById(ctx context.Context, id int32) (Product, error) {
@@ -21,12 +74,52 @@ func (q *Queries) GetOneById(ctx context.Context, id int32) (Product, error) {
 		&i.Name,
 		&i.Version,
 		&i.CreatedAt,
+		&i.KeyPrefix,
+		&i.KeyBytes,
+		&i.KeyGroupSize,
+		&i.RequireValidationNonce,
+		&i.TokenFormat,
+		&i.SigningAlg,
+		&i.TokenEncryptionKey,
+		&i.HwidMatchThreshold,
+		&i.ImportedKeyPrefixes,
 	)
 	return i, err
 }
 
+const getProductKeyPrefixes = `-- name: GetProductKeyPrefixes :many
+select id, key_prefix, imported_key_prefixes from products
+where key_prefix is not null or cardinality(imported_key_prefixes) > 0
+`
+
+type GetProductKeyPrefixesRow struct {
+	ID                  int32       `json:"id"`
+	KeyPrefix           pgtype.Text `json:"key_prefix"`
+	ImportedKeyPrefixes []string    `json:"imported_key_prefixes"`
+}
+
+func (q *Queries) GetProductKeyPrefixes(ctx context.Context) ([]GetProductKeyPrefixesRow, error) {
+	rows, err := q.db.Query(ctx, getProductKeyPrefixes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetProductKeyPrefixesRow{}
+	for rows.Next() {
+		var i GetProductKeyPrefixesRow
+		if err := rows.Scan(&i.ID, &i.KeyPrefix, &i.ImportedKeyPrefixes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProducts = `-- name: GetProducts :many
-select id, name, version, created_at from products
+select id, name, version, created_at, key_prefix, key_bytes, key_group_size, require_validation_nonce, token_format, signing_alg, token_encryption_key, hwid_match_threshold, imported_key_prefixes from products
 `
 
 func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
@@ -43,6 +136,15 @@ func (q *Queries) GetProducts(ctx context.Context) ([]Product, error) {
 			&i.Name,
 			&i.Version,
 			&i.CreatedAt,
+			&i.KeyPrefix,
+			&i.KeyBytes,
+			&i.KeyGroupSize,
+			&i.RequireValidationNonce,
+			&i.TokenFormat,
+			&i.SigningAlg,
+			&i.TokenEncryptionKey,
+			&i.HwidMatchThreshold,
+			&i.ImportedKeyPrefixes,
 		); err != nil {
 			return nil, err
 		}
@@ -12,13 +12,40 @@ import (
 
 type Querier interface {
 	ActivateLicense(ctx context.Context, arg ActivateLicenseParams) (int32, error)
+	ConsumeNonce(ctx context.Context, arg ConsumeNonceParams) (int64, error)
 	CountActivations(ctx context.Context, licenseID pgtype.Int4) (int64, error)
 	CreateLicense(ctx context.Context, arg CreateLicenseParams) (License, error)
+	CreateLicenseBatch(ctx context.Context, arg CreateLicenseBatchParams) (LicenseBatch, error)
+	CreateLicenses(ctx context.Context, arg []CreateLicensesParams) (int64, error)
+	CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error)
+	CreateSignedLicense(ctx context.Context, arg CreateSignedLicenseParams) (License, error)
+	DeactivateLicense(ctx context.Context, id int32) (License, error)
+	DeleteActivation(ctx context.Context, id int32) (Activation, error)
+	DeleteExpiredIssuedTokens(ctx context.Context) error
+	DeleteExpiredNonces(ctx context.Context) error
+	GetActivationByHwid(ctx context.Context, arg GetActivationByHwidParams) (Activation, error)
 	GetActivationsForLicense(ctx context.Context, licenseID pgtype.Int4) ([]Activation, error)
+	GetLicenseBatchById(ctx context.Context, id int32) (LicenseBatch, error)
 	GetLicenseByDigest(ctx context.Context, lookupDigest []byte) (License, error)
 	GetLicenseById(ctx context.Context, id int32) (License, error)
 	GetOneById(ctx context.Context, id int32) (Product, error)
+	GetProductKeyPrefixes(ctx context.Context) ([]GetProductKeyPrefixesRow, error)
+	GetProductLicenseByDigest(ctx context.Context, arg GetProductLicenseByDigestParams) (License, error)
 	GetProducts(ctx context.Context) ([]Product, error)
+	ImportLicense(ctx context.Context, arg ImportLicenseParams) (License, error)
+	IsRevoked(ctx context.Context, arg IsRevokedParams) (bool, error)
+	IssuedTokenExists(ctx context.Context, jti string) (bool, error)
+	LatestRevocationVersion(ctx context.Context) (int64, error)
+	ListActivationDrift(ctx context.Context, activationID int32) ([]ActivationDrift, error)
+	ListLicenses(ctx context.Context, arg ListLicensesParams) ([]License, error)
+	ListRevocationsSince(ctx context.Context, version int64) ([]Revocation, error)
+	NextRevocationVersion(ctx context.Context) (int64, error)
+	RecordActivationDrift(ctx context.Context, arg RecordActivationDriftParams) error
+	RecordIssuedToken(ctx context.Context, arg RecordIssuedTokenParams) error
+	RecordRevocation(ctx context.Context, arg RecordRevocationParams) (Revocation, error)
+	TouchActivation(ctx context.Context, id int32) error
+	UpdateActivationFingerprint(ctx context.Context, arg UpdateActivationFingerprintParams) error
+	UpdateLicenseKeyHash(ctx context.Context, arg UpdateLicenseKeyHashParams) error
 }
 
 var _ Querier = (*Queries)(nil)
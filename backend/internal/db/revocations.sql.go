@@ -0,0 +1,103 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: revocations.sql
+
+package db
+
+import (
+	"context"
+)
+
+const isRevoked = `-- name: IsRevoked :one
+select exists(select 1 from revocations where kind = $1 and subject = $2)
+`
+
+type IsRevokedParams struct {
+	Kind    RevocationKind `json:"kind"`
+	Subject string         `json:"subject"`
+}
+
+func (q *Queries) IsRevoked(ctx context.Context, arg IsRevokedParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isRevoked, arg.Kind, arg.Subject)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const latestRevocationVersion = `-- name: LatestRevocationVersion :one
+select version from revocation_counter
+`
+
+func (q *Queries) LatestRevocationVersion(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, latestRevocationVersion)
+	var version int64
+	err := row.Scan(&version)
+	return version, err
+}
+
+const listRevocationsSince = `-- name: ListRevocationsSince :many
+select id, kind, subject, revoked_at, version from revocations where version > $1 order by version
+`
+
+func (q *Queries) ListRevocationsSince(ctx context.Context, version int64) ([]Revocation, error) {
+	rows, err := q.db.Query(ctx, listRevocationsSince, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Revocation{}
+	for rows.Next() {
+		var i Revocation
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Subject,
+			&i.RevokedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const nextRevocationVersion = `-- name: NextRevocationVersion :one
+update revocation_counter set version = version + 1 returning version
+`
+
+func (q *Queries) NextRevocationVersion(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, nextRevocationVersion)
+	var version int64
+	err := row.Scan(&version)
+	return version, err
+}
+
+const recordRevocation = `-- name: RecordRevocation :one
+insert into revocations (kind, subject, version) values($1, $2, $3)
+on conflict (kind, subject) do update set revoked_at = revocations.revoked_at
+returning id, kind, subject, revoked_at, version
+`
+
+type RecordRevocationParams struct {
+	Kind    RevocationKind `json:"kind"`
+	Subject string         `json:"subject"`
+	Version int64          `json:"version"`
+}
+
+func (q *Queries) RecordRevocation(ctx context.Context, arg RecordRevocationParams) (Revocation, error) {
+	row := q.db.QueryRow(ctx, recordRevocation, arg.Kind, arg.Subject, arg.Version)
+	var i Revocation
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Subject,
+		&i.RevokedAt,
+		&i.Version,
+	)
+	return i, err
+}
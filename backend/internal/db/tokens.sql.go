@@ -0,0 +1,47 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: tokens.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteExpiredIssuedTokens = `-- name: DeleteExpiredIssuedTokens :exec
+delete from issued_tokens where expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredIssuedTokens(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredIssuedTokens)
+	return err
+}
+
+const issuedTokenExists = `-- name: IssuedTokenExists :one
+select exists(select 1 from issued_tokens where jti = $1)
+`
+
+func (q *Queries) IssuedTokenExists(ctx context.Context, jti string) (bool, error) {
+	row := q.db.QueryRow(ctx, issuedTokenExists, jti)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const recordIssuedToken = `-- name: RecordIssuedToken :exec
+insert into issued_tokens (jti, license_id, expires_at) values($1, $2, $3)
+`
+
+type RecordIssuedTokenParams struct {
+	Jti       string             `json:"jti"`
+	LicenseID int32              `json:"license_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) RecordIssuedToken(ctx context.Context, arg RecordIssuedTokenParams) error {
+	_, err := q.db.Exec(ctx, recordIssuedToken, arg.Jti, arg.LicenseID, arg.ExpiresAt)
+	return err
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	problem "github.com/cheetahbyte/problems"
+)
+
+// ListLicenses lists the newest licenses, optionally of one ?productId=,
+// at most ?limit= of them.
+func (h *Handlers) ListLicenses(w http.ResponseWriter, r *http.Request) {
+	var productID, limit int64
+	var err error
+	if v := r.URL.Query().Get("productId"); v != "" {
+		productID, err = strconv.ParseInt(v, 10, 32)
+	}
+	if v := r.URL.Query().Get("limit"); err == nil && v != "" {
+		limit, err = strconv.ParseInt(v, 10, 32)
+	}
+	if err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid query")).
+			Append(problem.Detail("productId and limit must be integers")))
+		return
+	}
+
+	result, err := h.Services.License().ListLicenses(r.Context(), int32(productID), int32(limit))
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handlers) GetLicense(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.idParam(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.Services.License().GetLicense(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handlers) ListActivations(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.idParam(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.Services.License().ListActivations(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
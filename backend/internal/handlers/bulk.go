@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	problem "github.com/cheetahbyte/problems"
+)
+
+type bulkKeyLine struct {
+	BatchID    int32  `json:"batchId"`
+	LicenseKey string `json:"licenseKey"`
+}
+
+// BulkCreateLicenses generates a batch of licenses and streams the plaintext
+// keys back as CSV (default) or JSON lines (?format=jsonl). The keys are only
+// ever returned by this response.
+func (h *Handlers) BulkCreateLicenses(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "jsonl" {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Unsupported format")).
+			Append(problem.Detail("format must be one of: csv, jsonl")))
+		return
+	}
+
+	var data dto.BulkLicenseCreationRequest
+	if err := decodeJSON(w, r, &data); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
+		return
+	}
+
+	result, err := h.Services.License().BulkCreateLicenses(r.Context(), data)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("X-Batch-Id", strconv.Itoa(int(result.BatchID)))
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch format {
+	case "jsonl":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+
+		enc := json.NewEncoder(w)
+		for _, key := range result.Keys {
+			if err := enc.Encode(bulkKeyLine{BatchID: result.BatchID, LicenseKey: key}); err != nil {
+				return
+			}
+		}
+	default:
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"batch-"+strconv.Itoa(int(result.BatchID))+".csv\"")
+		w.WriteHeader(http.StatusOK)
+
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"batch_id", "license_key"})
+		batchID := strconv.Itoa(int(result.BatchID))
+		for _, key := range result.Keys {
+			if err := cw.Write([]string{batchID, key}); err != nil {
+				return
+			}
+		}
+		cw.Flush()
+	}
+}
@@ -1,12 +1,50 @@
 package dto
 
+import "time"
+
 type ActivateLicenseRequest struct {
 	LicenseKey string `json:"licenseKey"`
 	DeviceID   string `json:"deviceId"`
 	ProductID  int32  `json:"productId"`
+	// DevicePublicKey is an optional base64 Ed25519 key. Tokens for the
+	// activation are then bound to it and must be presented with a proof.
+	DevicePublicKey string `json:"devicePublicKey,omitempty"`
+	// DeviceEncryptionKey is an optional base64 PKIX P-256 or RSA public key
+	// tokens for the activation are encrypted to.
+	DeviceEncryptionKey string `json:"deviceEncryptionKey,omitempty"`
+	// Components are the hashed hardware components behind DeviceID, by
+	// name. Products with a match threshold use them to recognise a device
+	// whose id changed with some of its hardware.
+	Components map[string]string `json:"components,omitempty"`
+	// Nonce and Proof let a device move an activation bound to a device
+	// key to its new id after hardware drift. Proof is the old key's
+	// signature over licensecrypto.RebindProofMessage.
+	Nonce string `json:"nonce,omitempty"`
+	Proof string `json:"proof,omitempty"`
 }
 
 type ActivateLicenseResponse struct {
 	ActivationId int32  `json:"activationId"`
 	Token        string `json:"token"`
 }
+
+// ActivationDrift is one recorded change of an activation's device id
+// after a partial fingerprint match.
+type ActivationDrift struct {
+	OldHwid   string    `json:"oldHwid"`
+	NewHwid   string    `json:"newHwid"`
+	Matched   int32     `json:"matched"`
+	Total     int32     `json:"total"`
+	Changed   []string  `json:"changed"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ActivationResponse struct {
+	ID          int32      `json:"id"`
+	LicenseID   int32      `json:"licenseId"`
+	DeviceID    string     `json:"deviceId"`
+	Mode        string     `json:"mode"`
+	DeviceBound bool       `json:"deviceBound"`
+	LastCheckIn *time.Time `json:"lastCheckIn,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
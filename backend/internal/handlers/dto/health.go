@@ -0,0 +1,7 @@
+package dto
+
+type ReadinessResponse struct {
+	Status string `json:"status"`
+	// Checks maps each check to "ok" or "failed".
+	Checks map[string]string `json:"checks"`
+}
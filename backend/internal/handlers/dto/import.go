@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type LicenseImportRow struct {
+	Line           int        `json:"-"`
+	LicenseKey     string     `json:"licenseKey"`
+	ProductID      int32      `json:"productId"`
+	MaxActivations int32      `json:"maxActivations"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	DeviceIDs      []string   `json:"deviceIds,omitempty"`
+}
+
+type LicenseImportRowResult struct {
+	Line        int    `json:"line"`
+	LicenseID   int32  `json:"licenseId,omitempty"`
+	Activations int    `json:"activations,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type LicenseImportResponse struct {
+	DryRun   bool                     `json:"dryRun"`
+	Imported int                      `json:"imported"`
+	Failed   int                      `json:"failed"`
+	Rows     []LicenseImportRowResult `json:"rows"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type LicenseCreationRequest struct {
 	ProductID      int32 `json:"productId"`
 	MaxActivations int32 `json:"maxActivations"`
@@ -8,3 +10,47 @@ type LicenseCreationRequest struct {
 type LicenseCreationResponse struct {
 	LicenseKey string `json:"licenseKey"`
 }
+
+type BulkLicenseCreationRequest struct {
+	ProductID      int32 `json:"productId"`
+	MaxActivations int32 `json:"maxActivations"`
+	Count          int   `json:"count"`
+}
+
+type BulkLicenseCreationResponse struct {
+	BatchID int32    `json:"batchId"`
+	Keys    []string `json:"keys"`
+}
+
+type SignedLicenseCreationRequest struct {
+	ProductID      int32      `json:"productId"`
+	MaxActivations int32      `json:"maxActivations"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	Features       []string   `json:"features,omitempty"`
+}
+
+type LicenseFileRequest struct {
+	// DeviceIDs restricts the file to these devices; empty means any.
+	DeviceIDs []string `json:"deviceIds,omitempty"`
+	// Format is json (default) or pem.
+	Format   string            `json:"format,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type LicenseResponse struct {
+	ID             int32      `json:"id"`
+	ProductID      int32      `json:"productId"`
+	MaxActivations int32      `json:"maxActivations"`
+	Active         bool       `json:"active"`
+	KeyType        string     `json:"keyType"`
+	Features       []string   `json:"features,omitempty"`
+	BatchID        int32      `json:"batchId,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// LicenseDetailResponse is a license with its activations.
+type LicenseDetailResponse struct {
+	LicenseResponse
+	Activations []ActivationResponse `json:"activations"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+import "time"
+
+type NonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
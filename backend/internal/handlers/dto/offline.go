@@ -0,0 +1,28 @@
+package dto
+
+// OfflineActivationBlob is what a client on an air-gapped machine writes to
+// its activation request file, base64url encoded.
+type OfflineActivationBlob struct {
+	LicenseKey string `json:"licenseKey"`
+	DeviceID   string `json:"deviceId"`
+	ProductID  int32  `json:"productId,omitempty"`
+	Nonce      string `json:"nonce"`
+
+	DevicePublicKey     string            `json:"devicePublicKey,omitempty"`
+	DeviceEncryptionKey string            `json:"deviceEncryptionKey,omitempty"`
+	Components          map[string]string `json:"components,omitempty"`
+	// Proof signs licensecrypto.RebindProofMessage over Nonce, as in
+	// ActivateLicenseRequest.
+	Proof string `json:"proof,omitempty"`
+}
+
+type OfflineActivationRequest struct {
+	Request string `json:"request"`
+}
+
+type OfflineActivationResponse struct {
+	ActivationId int32 `json:"activationId"`
+	// Response is a signed JWT to be carried back to the client as a file or
+	// QR code. It wraps the activation token and echoes the request nonce.
+	Response string `json:"response"`
+}
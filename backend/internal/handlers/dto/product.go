@@ -0,0 +1,42 @@
+package dto
+
+type ProductCreationRequest struct {
+	Name         string `json:"name"`
+	Version      string `json:"version,omitempty"`
+	KeyPrefix    string `json:"keyPrefix,omitempty"`
+	KeyBytes     int32  `json:"keyBytes,omitempty"`
+	KeyGroupSize int32  `json:"keyGroupSize,omitempty"`
+	// RequireValidationNonce makes /validate reject requests without a nonce.
+	RequireValidationNonce bool `json:"requireValidationNonce,omitempty"`
+	// TokenFormat is jwt (default), paseto_v4 or cwt.
+	TokenFormat string `json:"tokenFormat,omitempty"`
+	// SigningAlg is EdDSA (default), ES256 or RS256.
+	SigningAlg string `json:"signingAlg,omitempty"`
+	// TokenEncryptionKey is an optional base64 PKIX P-256 or RSA public key.
+	// Tokens are then encrypted to it unless the device brings its own key.
+	TokenEncryptionKey string `json:"tokenEncryptionKey,omitempty"`
+	// HwidMatchThreshold is how many hardware components a device must
+	// share with an activation to take it over after its id changed. Zero
+	// (default) only accepts exact device ids.
+	HwidMatchThreshold int32 `json:"hwidMatchThreshold,omitempty"`
+	// ImportedKeyPrefixes lists the prefixes of keys imported from other
+	// systems. Keys without a check group are only accepted with one of them.
+	ImportedKeyPrefixes []string `json:"importedKeyPrefixes,omitempty"`
+}
+
+type ProductResponse struct {
+	ID           int32  `json:"id"`
+	Name         string `json:"name"`
+	Version      string `json:"version,omitempty"`
+	KeyPrefix    string `json:"keyPrefix"`
+	KeyBytes     int32  `json:"keyBytes"`
+	KeyGroupSize int32  `json:"keyGroupSize"`
+
+	RequireValidationNonce bool   `json:"requireValidationNonce"`
+	TokenFormat            string `json:"tokenFormat"`
+	SigningAlg             string `json:"signingAlg"`
+	TokenEncryptionKey     string `json:"tokenEncryptionKey,omitempty"`
+	HwidMatchThreshold     int32  `json:"hwidMatchThreshold"`
+
+	ImportedKeyPrefixes []string `json:"importedKeyPrefixes"`
+}
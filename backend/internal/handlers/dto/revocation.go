@@ -0,0 +1,8 @@
+package dto
+
+type RevocationListResponse struct {
+	Version int64 `json:"version"`
+	// List is a signed JWT carrying the revocations after the requested
+	// version.
+	List string `json:"list"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "time"
+
+type TimeResponse struct {
+	Time time.Time `json:"time"`
+	// Assertion is a signed JWT carrying the same time.
+	Assertion string `json:"assertion"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// TokenRevocationRequest identifies the token to revoke, either by the token
+// itself or by its jti.
+type TokenRevocationRequest struct {
+	Token string `json:"token,omitempty"`
+	JTI   string `json:"jti,omitempty"`
+}
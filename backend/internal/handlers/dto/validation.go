@@ -3,8 +3,26 @@ package dto
 type LicenseValidationRequest struct {
 	Token    string `json:"token"`
 	DeviceID string `json:"deviceId"`
+	// Nonce is a challenge from /nonce, or 16 to 64 random bytes generated
+	// by the client, base64url encoded, and never sent twice. It is echoed
+	// in the new token so the client can tell a fresh response from a
+	// replayed one. Tokens bound to a device key need a nonce from /nonce,
+	// together with Proof: the device's signature over the nonce and the
+	// token.
+	Nonce string `json:"nonce,omitempty"`
+	Proof string `json:"proof,omitempty"`
+	// Components are the device's hashed hardware components, used when
+	// DeviceID no longer matches the token; see ActivateLicenseRequest.
+	Components map[string]string `json:"components,omitempty"`
 }
 
 type LicenseValidationResponse struct {
 	Token string `json:"token"`
 }
+
+type HeartbeatRequest struct {
+	Token    string `json:"token"`
+	DeviceID string `json:"deviceId"`
+	Nonce    string `json:"nonce,omitempty"`
+	Proof    string `json:"proof,omitempty"`
+}
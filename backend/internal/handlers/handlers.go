@@ -5,12 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
 	"strings"
 
+	"github.com/cheetahbyte/clave/internal/logging"
 	"github.com/cheetahbyte/clave/internal/services"
 	problem "github.com/cheetahbyte/problems"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 type Handlers struct {
@@ -54,24 +55,26 @@ func decodeJSON[T any](w http.ResponseWriter, r *http.Request, dst *T) error {
 	return nil
 }
 
+// writeError writes err as a problem, tagged with the request ID so a
+// client can quote it to support.
 func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, err error) {
 	var p *problem.Problem
 
-	if errors.As(err, &p) {
-		p.WriteTo(w)
-		return
-	}
-
-	slog.Error("unhandled error",
-		"path", r.URL.Path,
-		"method", r.Method,
-		"err", err,
-	)
+	if !errors.As(err, &p) {
+		logging.FromContext(r.Context()).Error("unhandled error",
+			"path", r.URL.Path,
+			"method", r.Method,
+			"err", err,
+		)
 
-	p = problem.Of(http.StatusInternalServerError).
-		Append(problem.Type("https://api.yourapp.dev/problems/internal")).
-		Append(problem.Title("Internal Server Error")).
-		Append(problem.Detail("An unexpected error occurred"))
+		p = problem.Of(http.StatusInternalServerError).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal Server Error")).
+			Append(problem.Detail("An unexpected error occurred"))
+	}
 
+	if id := middleware.GetReqID(r.Context()); id != "" {
+		p.Append(problem.Ext("requestId", id))
+	}
 	p.WriteTo(w)
 }
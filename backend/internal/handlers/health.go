@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// Healthz reports that the process is up. It checks nothing else, so an
+// orchestrator does not restart instances for a database outage.
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz reports whether the instance can serve licensing requests.
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	result, ready := h.Services.Health().Ready(r.Context())
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, result)
+}
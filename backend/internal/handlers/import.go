@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	problem "github.com/cheetahbyte/problems"
+)
+
+// ImportLicenses imports licenses from a CSV (default) or JSONL
+// (?format=jsonl) request body. ?dryRun=true checks every row without
+// persisting anything. Per-row failures are reported in the response and do
+// not fail the request.
+func (h *Handlers) ImportLicenses(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dryRun"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			h.writeError(w, r, problem.Of(http.StatusBadRequest).
+				Append(problem.Title("Invalid dryRun")).
+				Append(problem.Detail("dryRun must be a boolean")))
+			return
+		}
+		dryRun = b
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 32<<20)
+
+	result, err := h.Services.License().ImportLicenses(r.Context(), r.Body, format, dryRun)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
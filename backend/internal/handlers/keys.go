@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// JWKS publishes the public halves of all signing keys so clients can pick
+// the verification key by the kid of a token.
+func (h *Handlers) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, h.Services.Keys().JWKS())
+}
@@ -2,10 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
-	"log/slog"
 	"net/http"
 
 	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
 )
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
@@ -17,23 +18,47 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 func (h *Handlers) CreateLicense(w http.ResponseWriter, r *http.Request) {
 	var data dto.LicenseCreationRequest
 	if err := decodeJSON(w, r, &data); err != nil {
-		slog.Error("failed to read body", "err", err.Error())
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
 		return
 	}
 
 	result, err := h.Services.License().NewLicense(r.Context(), data)
 
 	if err != nil {
-		slog.Error("failed to create license", "err", err.Error())
+		logging.FromContext(r.Context()).Error("failed to create license", "err", err.Error())
+		h.writeError(w, r, err)
 		return
 	}
 
 	writeJSON(w, 200, result)
 }
 
+func (h *Handlers) CreateSignedLicense(w http.ResponseWriter, r *http.Request) {
+	var data dto.SignedLicenseCreationRequest
+	if err := decodeJSON(w, r, &data); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
+		return
+	}
+
+	result, err := h.Services.License().NewSignedLicense(r.Context(), data)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
 func (h *Handlers) ActivateLicense(w http.ResponseWriter, r *http.Request) {
 	var data dto.ActivateLicenseRequest
 	if err := decodeJSON(w, r, &data); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
 		return
 	}
 
@@ -46,9 +71,32 @@ func (h *Handlers) ActivateLicense(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
+// ActivateOffline takes an activation request blob uploaded by an operator
+// for an air-gapped machine and returns the signed response to carry back.
+func (h *Handlers) ActivateOffline(w http.ResponseWriter, r *http.Request) {
+	var data dto.OfflineActivationRequest
+	if err := decodeJSON(w, r, &data); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
+		return
+	}
+
+	result, err := h.Services.License().ActivateOffline(r.Context(), data)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
 func (h *Handlers) ValidateLicense(w http.ResponseWriter, r *http.Request) {
 	var data dto.LicenseValidationRequest
 	if err := decodeJSON(w, r, &data); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
 		return
 	}
 
@@ -60,3 +108,33 @@ func (h *Handlers) ValidateLicense(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, result)
 }
+
+func (h *Handlers) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	var data dto.HeartbeatRequest
+	if err := decodeJSON(w, r, &data); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
+		return
+	}
+
+	if err := h.Services.Validation().Heartbeat(r.Context(), data); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// IssueNonce hands out a short-lived challenge for validation requests and
+// device proofs.
+func (h *Handlers) IssueNonce(w http.ResponseWriter, r *http.Request) {
+	result, err := h.Services.Nonce().Issue()
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	problem "github.com/cheetahbyte/problems"
+)
+
+// ExportLicenseFile serves a signed .lic file as an attachment.
+func (h *Handlers) ExportLicenseFile(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.idParam(w, r)
+	if !ok {
+		return
+	}
+
+	var data dto.LicenseFileRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, &data); err != nil {
+			h.writeError(w, r, problem.Of(http.StatusBadRequest).
+				Append(problem.Title("Invalid request body")).
+				Append(problem.Detail(err.Error())))
+			return
+		}
+	}
+
+	file, err := h.Services.License().ExportLicenseFile(r.Context(), id, data)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	contentType := "application/json"
+	if data.Format == "pem" {
+		contentType = "application/x-pem-file"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="license-%d.lic"`, id))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(file)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	problem "github.com/cheetahbyte/problems"
+)
+
+func (h *Handlers) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var data dto.ProductCreationRequest
+	if err := decodeJSON(w, r, &data); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
+		return
+	}
+
+	result, err := h.Services.Product().CreateProduct(r.Context(), data)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, result)
+}
+
+func (h *Handlers) ListProducts(w http.ResponseWriter, r *http.Request) {
+	result, err := h.Services.Product().ListProducts(r.Context())
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	problem "github.com/cheetahbyte/problems"
+	"github.com/go-chi/chi/v5"
+)
+
+func (h *Handlers) RevokeLicense(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.idParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Services.Revocation().RevokeLicense(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) RemoveActivation(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.idParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Services.Revocation().RevokeActivation(r.Context(), id); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ActivationDrift lists the device id changes of an activation.
+func (h *Handlers) ActivationDrift(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.idParam(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.Services.License().ActivationDrift(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// RevocationList serves the signed revocation list. Clients pass the version
+// they already have as ?since= to receive only newer entries.
+func (h *Handlers) RevocationList(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.writeError(w, r, problem.Of(http.StatusBadRequest).
+				Append(problem.Title("Invalid since")).
+				Append(problem.Detail("since must be a revocation list version")))
+			return
+		}
+		since = n
+	}
+
+	result, err := h.Services.Revocation().List(r.Context(), since)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	writeJSON(w, http.StatusOK, result)
+}
+
+// idParam reads the {id} URL parameter, writing a problem if it is invalid.
+func (h *Handlers) idParam(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 32)
+	if err != nil || id <= 0 {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid id")).
+			Append(problem.Detail("id must be a positive integer")))
+		return 0, false
+	}
+	return int32(id), true
+}
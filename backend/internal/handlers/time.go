@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	problem "github.com/cheetahbyte/problems"
+)
+
+// ServerTime returns a signed time assertion. Clients may pass ?nonce= to
+// have it echoed in the assertion.
+func (h *Handlers) ServerTime(w http.ResponseWriter, r *http.Request) {
+	result, err := h.Services.Time().Assert(r.URL.Query().Get("nonce"))
+	if err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid nonce")).
+			Append(problem.Detail(err.Error())))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	problem "github.com/cheetahbyte/problems"
+)
+
+func (h *Handlers) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	var data dto.TokenRevocationRequest
+	if err := decodeJSON(w, r, &data); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
+		return
+	}
+
+	if err := h.Services.Token().Revoke(r.Context(), data); err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// IntrospectToken implements RFC 7662: the token is posted form encoded and
+// the response always has status 200, with active=false for any token that
+// would be rejected.
+func (h *Handlers) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if err := r.ParseForm(); err != nil {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Invalid request body")).
+			Append(problem.Detail(err.Error())))
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		h.writeError(w, r, problem.Of(http.StatusBadRequest).
+			Append(problem.Title("Missing token")).
+			Append(problem.Detail("The token parameter is required")))
+		return
+	}
+
+	result, err := h.Services.Token().Introspect(r.Context(), token)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, result)
+}
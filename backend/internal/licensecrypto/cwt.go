@@ -0,0 +1,203 @@
+package licensecrypto
+
+import (
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/veraison/go-cose"
+)
+
+// cwtClaims is the CBOR form of LicenseClaims. Registered claims use their
+// RFC 8392 integer keys; the license specific claims keep their JWT names.
+type cwtClaims struct {
+	Subject   string        `cbor:"2,keyasint,omitempty"`
+	Audience  string        `cbor:"3,keyasint,omitempty"`
+	ExpiresAt int64         `cbor:"4,keyasint,omitempty"`
+	NotBefore int64         `cbor:"5,keyasint,omitempty"`
+	IssuedAt  int64         `cbor:"6,keyasint,omitempty"`
+	CTI       []byte        `cbor:"7,keyasint,omitempty"`
+	Cnf       *Confirmation `cbor:"8,keyasint,omitempty"`
+
+	ProductID  int32    `cbor:"product_id"`
+	HWID       string   `cbor:"hwid,omitempty"`
+	Features   []string `cbor:"features,omitempty"`
+	LicenseExp *int64   `cbor:"license_exp,omitempty"`
+	Nonce      string   `cbor:"nonce,omitempty"`
+	ServerTime int64    `cbor:"server_time,omitempty"`
+}
+
+// cwtEncMode encodes claims as deterministic CBOR (RFC 8949, section
+// 4.2.1).
+var cwtEncMode = mustEncMode(cbor.CoreDetEncOptions())
+
+func mustEncMode(opts cbor.EncOptions) cbor.EncMode {
+	em, err := opts.EncMode()
+	if err != nil {
+		panic("licensecrypto: invalid CBOR encoding options: " + err.Error())
+	}
+	return em
+}
+
+// cwtCodec implements CBOR Web Tokens signed as COSE_Sign1 with EdDSA, for
+// clients where JSON and base64 parsing are a burden. On JSON APIs the
+// token travels base64url encoded.
+type cwtCodec struct{}
+
+// coseAlgorithms maps keyring algorithms to COSE ones. RS256 has no
+// COSE_Sign1 signer, so RSA keyed products stay on JWT.
+var coseAlgorithms = map[string]cose.Algorithm{
+	AlgEdDSA: cose.AlgorithmEdDSA,
+	AlgES256: cose.AlgorithmES256,
+}
+
+func (cwtCodec) supports(alg string) bool {
+	_, ok := coseAlgorithms[alg]
+	return ok
+}
+
+func (cwtCodec) encode(claims *LicenseClaims, key SigningKey) (string, error) {
+	c := cwtClaims{
+		Subject:    claims.Subject,
+		Cnf:        claims.Cnf,
+		ProductID:  claims.ProductID,
+		HWID:       claims.HWID,
+		Features:   claims.Features,
+		LicenseExp: claims.LicenseExp,
+		Nonce:      claims.Nonce,
+		ServerTime: claims.ServerTime,
+	}
+	if len(claims.Audience) > 1 {
+		return "", errors.New("cwt tokens support a single audience")
+	}
+	if len(claims.Audience) == 1 {
+		c.Audience = claims.Audience[0]
+	}
+	if claims.ExpiresAt != nil {
+		c.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		c.NotBefore = claims.NotBefore.Unix()
+	}
+	if claims.IssuedAt != nil {
+		c.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.ID != "" {
+		cti, err := base64.RawURLEncoding.DecodeString(claims.ID)
+		if err != nil {
+			return "", errors.New("token id is not base64url")
+		}
+		c.CTI = cti
+	}
+
+	payload, err := cwtEncMode.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	alg, ok := coseAlgorithms[key.Alg]
+	if !ok {
+		return "", fmt.Errorf("unsupported signing algorithm %q", key.Alg)
+	}
+	signer, err := cose.NewSigner(alg, key.Signer)
+	if err != nil {
+		return "", err
+	}
+
+	msg := cose.NewSign1Message()
+	msg.Headers.Protected.SetAlgorithm(alg)
+	msg.Headers.Protected[cose.HeaderLabelKeyID] = []byte(key.ID)
+	msg.Payload = payload
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		return "", err
+	}
+
+	raw, err := msg.MarshalCBOR()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func (cwtCodec) decode(token string, keys KeySet) (*LicenseClaims, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("malformed token")
+	}
+
+	var msg cose.Sign1Message
+	if err := msg.UnmarshalCBOR(raw); err != nil {
+		return nil, errors.New("malformed token")
+	}
+
+	alg, err := msg.Headers.Protected.Algorithm()
+	if err != nil {
+		return nil, errors.New("unexpected signing algorithm")
+	}
+	kid, _ := msg.Headers.Protected[cose.HeaderLabelKeyID].([]byte)
+
+	var key crypto.PublicKey
+	for name, a := range coseAlgorithms {
+		if a == alg {
+			key, err = keys.find(string(kid), name)
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.New("unexpected signing algorithm")
+	}
+
+	verifier, err := cose.NewVerifier(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := msg.Verify(nil, verifier); err != nil {
+		return nil, errors.New("invalid token signature")
+	}
+
+	var c cwtClaims
+	if err := cbor.Unmarshal(msg.Payload, &c); err != nil {
+		return nil, err
+	}
+
+	claims := &LicenseClaims{
+		ProductID:  c.ProductID,
+		HWID:       c.HWID,
+		Features:   c.Features,
+		LicenseExp: c.LicenseExp,
+		Cnf:        c.Cnf,
+		Nonce:      c.Nonce,
+		ServerTime: c.ServerTime,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: c.Subject,
+		},
+	}
+	if c.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{c.Audience}
+	}
+	if c.ExpiresAt != 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(unixTime(c.ExpiresAt))
+	}
+	if c.NotBefore != 0 {
+		claims.NotBefore = jwt.NewNumericDate(unixTime(c.NotBefore))
+	}
+	if c.IssuedAt != 0 {
+		claims.IssuedAt = jwt.NewNumericDate(unixTime(c.IssuedAt))
+	}
+	if len(c.CTI) > 0 {
+		claims.ID = base64.RawURLEncoding.EncodeToString(c.CTI)
+	}
+	return claims, nil
+}
+
+func unixTime(secs int64) time.Time {
+	return time.Unix(secs, 0)
+}
@@ -0,0 +1,75 @@
+package licensecrypto
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OfflineActivationType is the typ header of offline activation responses.
+const OfflineActivationType = "clave-offline-activation+jwt"
+
+// OfflineActivationClaims answer an offline activation request. They wrap
+// the license token and echo the request nonce, so the response can only be
+// imported on the machine that asked for it.
+type OfflineActivationClaims struct {
+	Nonce        string `json:"nonce"`
+	HWID         string `json:"hwid"`
+	ActivationID int32  `json:"activation_id"`
+	Token        string `json:"token"`
+
+	jwt.RegisteredClaims
+}
+
+// RevocationListType is the typ header of signed revocation lists.
+const RevocationListType = "clave-revocation-list+jwt"
+
+// Revocation subject kinds, as stored in RevocationEntry.Kind.
+const (
+	RevokedLicense    = "license"
+	RevokedActivation = "activation"
+	RevokedToken      = "token"
+)
+
+// RevocationEntry is one revoked license, activation or token. ID is the
+// license or activation id in decimal, or the token's jti.
+type RevocationEntry struct {
+	Version   int64  `json:"ver"`
+	Kind      string `json:"kind"`
+	ID        string `json:"id"`
+	RevokedAt int64  `json:"revoked_at"`
+}
+
+// RevocationListClaims is the signed revocation list. Since is the version
+// the client already had; a client applies Revocations and remembers
+// Version for its next request.
+type RevocationListClaims struct {
+	Version     int64             `json:"ver"`
+	Since       int64             `json:"since"`
+	Revocations []RevocationEntry `json:"revocations"`
+
+	jwt.RegisteredClaims
+}
+
+// SignDocument signs claims as an EdDSA JWT of the given type. Documents
+// other than license tokens, such as offline activation responses, are told
+// apart by their typ header, so one can never be passed off as another.
+func SignDocument(claims jwt.Claims, typ string, priv ed25519.PrivateKey) (string, error) {
+	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	tok.Header["typ"] = typ
+	return tok.SignedString(priv)
+}
+
+// VerifyDocument checks the type and signature of a document created by
+// SignDocument, decodes it into claims and validates its time based claims.
+func VerifyDocument(token, typ string, claims jwt.Claims, keys KeySet) error {
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		if got, _ := t.Header["typ"].(string); got != typ {
+			return nil, fmt.Errorf("unexpected document type %q", got)
+		}
+		kid, _ := t.Header["kid"].(string)
+		return keys.find(kid, t.Method.Alg())
+	}, jwt.WithValidMethods([]string{AlgEdDSA}))
+	return err
+}
@@ -0,0 +1,50 @@
+package licensecrypto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerifyDocument(t *testing.T) {
+	pub, priv := newTestEd25519(t)
+	other, _ := newTestEd25519(t)
+	keys := KeySet{{Alg: AlgEdDSA, Key: pub}}
+
+	claims := &OfflineActivationClaims{
+		Nonce: "n",
+		HWID:  "device-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	signed, err := SignDocument(claims, OfflineActivationType, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got OfflineActivationClaims
+	if err := VerifyDocument(signed, OfflineActivationType, &got, keys); err != nil {
+		t.Fatal(err)
+	}
+	if got.Nonce != "n" || got.HWID != "device-1" {
+		t.Errorf("VerifyDocument() = %+v", got)
+	}
+
+	if err := VerifyDocument(signed, "other+jwt", &OfflineActivationClaims{}, keys); err == nil {
+		t.Error("document accepted as another type")
+	}
+	if err := VerifyDocument(signed, OfflineActivationType, &OfflineActivationClaims{}, KeySet{{Alg: AlgEdDSA, Key: other}}); err == nil {
+		t.Error("document accepted with the wrong key")
+	}
+
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	expired, err := SignDocument(claims, OfflineActivationType, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyDocument(expired, OfflineActivationType, &OfflineActivationClaims{}, keys); err == nil {
+		t.Error("expired document accepted")
+	}
+}
@@ -0,0 +1,118 @@
+package licensecrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+var ErrNotEncrypted = errors.New("token is not encrypted")
+
+// ParseEncryptionKey decodes a base64 PKIX public key that tokens can be
+// encrypted to. P-256 and RSA keys of at least 2048 bits are supported.
+func ParseEncryptionKey(b64 string) (crypto.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := keyEncryption(pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+func keyEncryption(pub crypto.PublicKey) (jose.KeyAlgorithm, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return "", errors.New("ecdsa keys must use P-256")
+		}
+		return jose.ECDH_ES_A256KW, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return "", fmt.Errorf("rsa keys must have at least %d bits", minRSABits)
+		}
+		return jose.RSA_OAEP_256, nil
+	default:
+		return "", fmt.Errorf("unsupported key type %T", pub)
+	}
+}
+
+// EncryptToken wraps a signed token in a compact JWE for the holder of the
+// private half of pub, so its claims are only readable after decryption.
+// The kid header is the recipient key's thumbprint.
+func EncryptToken(token string, pub crypto.PublicKey) (string, error) {
+	alg, err := keyEncryption(pub)
+	if err != nil {
+		return "", err
+	}
+	jwk, err := PublicJWK(pub)
+	if err != nil {
+		return "", err
+	}
+
+	enc, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{
+		Algorithm: alg,
+		Key:       pub,
+		KeyID:     jwk.Thumbprint(),
+	}, (&jose.EncrypterOptions{}).WithContentType("JWT"))
+	if err != nil {
+		return "", err
+	}
+
+	obj, err := enc.Encrypt([]byte(token))
+	if err != nil {
+		return "", err
+	}
+	return obj.CompactSerialize()
+}
+
+// IsEncrypted reports whether a token is a compact JWE.
+func IsEncrypted(token string) bool {
+	return strings.Count(token, ".") == 4
+}
+
+// DecryptToken opens a token made by EncryptToken with the matching P-256
+// or RSA private key and returns the signed token inside. The result still
+// has to be verified.
+func DecryptToken(token string, priv crypto.PrivateKey) (string, error) {
+	if !IsEncrypted(token) {
+		return "", ErrNotEncrypted
+	}
+
+	k, ok := priv.(interface{ Public() crypto.PublicKey })
+	if !ok {
+		return "", fmt.Errorf("unsupported key type %T", priv)
+	}
+	alg, err := keyEncryption(k.Public())
+	if err != nil {
+		return "", err
+	}
+
+	obj, err := jose.ParseEncryptedCompact(token,
+		[]jose.KeyAlgorithm{alg},
+		[]jose.ContentEncryption{jose.A256GCM},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := obj.Decrypt(priv)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,150 @@
+package licensecrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryptTokenRoundTrip(t *testing.T) {
+	keys := newTestKeyring(t)
+	signing, ok := keys.Signer(AlgEdDSA)
+	if !ok {
+		t.Fatal("no EdDSA key")
+	}
+	signed, err := SignToken(testClaims(time.Now()), FormatJWT, signing)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, priv := range map[string]crypto.Signer{"P-256": ecKey, "RSA": rsaKey} {
+		t.Run(name, func(t *testing.T) {
+			enc, err := EncryptToken(signed, priv.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsEncrypted(enc) || IsEncrypted(signed) {
+				t.Fatal("IsEncrypted does not tell the JWE from the JWS")
+			}
+			if strings.Contains(enc, strings.Split(signed, ".")[1]) {
+				t.Fatal("claims are readable in the JWE")
+			}
+
+			header := jweHeader(t, enc)
+			jwk, err := PublicJWK(priv.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header["kid"] != jwk.Thumbprint() || header["cty"] != "JWT" || header["enc"] != "A256GCM" {
+				t.Errorf("header = %v, want the recipient's thumbprint, cty JWT and A256GCM", header)
+			}
+
+			inner, err := DecryptToken(enc, priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inner != signed {
+				t.Fatal("decrypted token differs from the signed one")
+			}
+			if _, err := ParseToken(inner, keys.Public()); err != nil {
+				t.Errorf("decrypted token does not verify: %v", err)
+			}
+		})
+	}
+
+	enc, err := EncryptToken(signed, ecKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptToken(enc, other); err == nil {
+		t.Error("DecryptToken with another key succeeded")
+	}
+	if _, err := DecryptToken(enc, rsaKey); err == nil {
+		t.Error("DecryptToken with a key of another type succeeded")
+	}
+
+	parts := strings.Split(enc, ".")
+	ct, _ := base64.RawURLEncoding.DecodeString(parts[3])
+	ct[0] ^= 1
+	parts[3] = base64.RawURLEncoding.EncodeToString(ct)
+	if _, err := DecryptToken(strings.Join(parts, "."), ecKey); err == nil {
+		t.Error("DecryptToken accepted a tampered ciphertext")
+	}
+
+	if _, err := DecryptToken(signed, ecKey); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("DecryptToken(JWS) = %v, want ErrNotEncrypted", err)
+	}
+}
+
+func jweHeader(t *testing.T, token string) map[string]any {
+	t.Helper()
+	raw, err := base64.RawURLEncoding.DecodeString(strings.SplitN(token, ".", 2)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h map[string]any
+	if err := json.Unmarshal(raw, &h); err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	der := func(pub crypto.PublicKey) string {
+		t.Helper()
+		b, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	rsa2048, _ := rsa.GenerateKey(rand.Reader, minRSABits)
+	rsa1024, _ := rsa.GenerateKey(rand.Reader, 1024)
+	ed, _ := newTestEd25519(t)
+
+	tests := []struct {
+		name string
+		b64  string
+		ok   bool
+	}{
+		{"P-256", der(p256.Public()), true},
+		{"RSA 2048", der(rsa2048.Public()), true},
+		{"P-384", der(p384.Public()), false},
+		{"RSA 1024", der(rsa1024.Public()), false},
+		{"Ed25519", der(ed), false},
+		{"not base64", "!!", false},
+		{"not a key", base64.StdEncoding.EncodeToString([]byte("key")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseEncryptionKey(tt.b64)
+			if (err == nil) != tt.ok {
+				t.Errorf("ParseEncryptionKey() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -2,10 +2,24 @@ package licensecrypto
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base32"
+	"errors"
 	"strings"
 )
 
+// DefaultPrefix is the prefix of keys generated by GenerateLicenseKey.
+const DefaultPrefix = "LIC"
+
+// crockford is Crockford's base32 alphabet. It leaves out I, L, O and U so a
+// key can be read out or retyped without ambiguity.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockfordEncoding = base32.NewEncoding(crockford).WithPadding(base32.NoPadding)
+
+// checkLen is the number of symbols in the trailing check group.
+const checkLen = 4
+
 func formatKey(prefix, raw string, groupSize int) string {
 	raw = strings.ToUpper(raw)
 
@@ -21,16 +35,102 @@ func formatKey(prefix, raw string, groupSize int) string {
 	return prefix + "-" + strings.Join(parts, "-")
 }
 
+// KeySpec describes the shape of generated keys. Products can override
+// the default to get their own prefix, entropy and grouping.
+type KeySpec struct {
+	Prefix    string
+	Bytes     int
+	GroupSize int
+}
+
+// DefaultKeySpec is used for products without their own key format.
+var DefaultKeySpec = KeySpec{Prefix: DefaultPrefix, Bytes: 15, GroupSize: 4}
+
+// Validate rejects specs that would produce unusable or ambiguous keys.
+func (s KeySpec) Validate() error {
+	if len(s.Prefix) < 2 || len(s.Prefix) > 8 {
+		return errors.New("key prefix must be 2 to 8 letters")
+	}
+	for _, r := range s.Prefix {
+		if r < 'A' || r > 'Z' {
+			return errors.New("key prefix must only contain the letters A-Z")
+		}
+	}
+	if s.Bytes < 10 || s.Bytes > 32 {
+		return errors.New("key entropy must be between 10 and 32 bytes")
+	}
+	if s.GroupSize < 3 || s.GroupSize > 8 {
+		return errors.New("key group size must be between 3 and 8")
+	}
+	return nil
+}
+
+// CanonicalPrefix upper-cases a product's key prefix and checks it against
+// the canonical forms keys are matched by. Prefixes may only use letters of
+// the key alphabet, so no two of them, and none of them and DefaultPrefix,
+// read the same once O, I and L are taken for 0, 1 and 1.
+func CanonicalPrefix(prefix string) (string, error) {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+
+	if canonicalize(prefix) == canonicalize(DefaultPrefix) {
+		return "", errors.New("key prefix " + DefaultPrefix + " is reserved")
+	}
+	for _, r := range prefix {
+		if r < 'A' || r > 'Z' || !strings.ContainsRune(crockford, r) {
+			return "", errors.New("key prefix must only contain the letters A-Z except I, L, O and U")
+		}
+	}
+	if err := (KeySpec{Prefix: prefix, Bytes: DefaultKeySpec.Bytes, GroupSize: DefaultKeySpec.GroupSize}).Validate(); err != nil {
+		return "", err
+	}
+	return prefix, nil
+}
+
+// ImportedPrefix upper-cases the prefix of keys imported from another
+// system. Such prefixes may use any letter or digit, since the keys were
+// not made by us, but must not read as DefaultPrefix.
+func ImportedPrefix(prefix string) (string, error) {
+	prefix = strings.ToUpper(strings.TrimSpace(prefix))
+
+	if len(prefix) < 2 || len(prefix) > 16 {
+		return "", errors.New("imported key prefix must be 2 to 16 characters")
+	}
+	if compact(prefix) != prefix {
+		return "", errors.New("imported key prefix must only contain letters and digits")
+	}
+	if canonicalize(prefix) == canonicalize(DefaultPrefix) {
+		return "", errors.New("key prefix " + DefaultPrefix + " is reserved")
+	}
+	return prefix, nil
+}
+
+// GenerateLicenseKey returns a new key in DefaultKeySpec.
 func GenerateLicenseKey() (string, error) {
-	b := make([]byte, 15)
+	return GenerateKey(DefaultKeySpec)
+}
+
+// GenerateKey returns a new key in the checksummed format: the prefix, the
+// random bytes as grouped Crockford base32 and a trailing check group,
+// e.g. LIC-7Q2M-...-K3ZD-9XHA.
+func GenerateKey(spec KeySpec) (string, error) {
+	if err := spec.Validate(); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, spec.Bytes)
 	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 
-	enc := base32.StdEncoding.
-		WithPadding(base32.NoPadding)
+	raw := crockfordEncoding.EncodeToString(b)
+	check := checksum(canonicalize(spec.Prefix + raw))
 
-	raw := enc.EncodeToString(b)
+	return formatKey(spec.Prefix, raw, spec.GroupSize) + "-" + check, nil
+}
 
-	return formatKey("LIC", raw, 4), nil
+// checksum derives the check group from the canonical form of everything
+// before it.
+func checksum(canonical string) string {
+	sum := sha256.Sum256([]byte(canonical))
+	return crockfordEncoding.EncodeToString(sum[:3])[:checkLen]
 }
@@ -0,0 +1,145 @@
+package licensecrypto
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalPrefix(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "acme", want: "ACME"},
+		{in: " XYZ ", want: "XYZ"},
+		{in: "LIC", wantErr: true},
+		{in: "lic", wantErr: true},
+		// read the same as LIC once canonicalized
+		{in: "LLC", wantErr: true},
+		{in: "IIC", wantErr: true},
+		{in: "ILC", wantErr: true},
+		// O, I, L and U are not part of the key alphabet
+		{in: "ALI", wantErr: true},
+		{in: "AIL", wantErr: true},
+		{in: "FOO", wantErr: true},
+		{in: "UVW", wantErr: true},
+		{in: "A1", wantErr: true},
+		{in: "A-B", wantErr: true},
+		{in: "A", wantErr: true},
+		{in: "ABCDEFGHJ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := CanonicalPrefix(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CanonicalPrefix(%q) = %q, %v; want error %v", tt.in, got, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CanonicalPrefix(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if err == nil && canonicalize(got) != got {
+				t.Errorf("prefix %q changes when canonicalized", got)
+			}
+		})
+	}
+}
+
+func TestImportedPrefix(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "foo", want: "FOO"},
+		{in: " Old1 ", want: "OLD1"},
+		{in: "SERIAL2019", want: "SERIAL2019"},
+		{in: "LIC", wantErr: true},
+		{in: "L1C", wantErr: true},
+		{in: "A-B", wantErr: true},
+		{in: "A", wantErr: true},
+		{in: "ABCDEFGHIJKLMNOPQ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ImportedPrefix(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ImportedPrefix(%q) = %q, %v; want error %v", tt.in, got, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ImportedPrefix(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPrefix(t *testing.T) {
+	prefixes := []string{"ACME", "ACM", "XY", DefaultPrefix}
+
+	tests := []struct {
+		key    string
+		want   string
+		wantOk bool
+	}{
+		{key: "ACME-0A1B-2C3D", want: "ACME", wantOk: true},
+		{key: "acm-0A1B-2C3D", want: "ACM", wantOk: true},
+		{key: "ACME0A1B2C3D", want: "ACME", wantOk: true},
+		{key: "ACMX0A1B2C3D", want: "ACM", wantOk: true},
+		{key: "XY 0A1B", want: "XY", wantOk: true},
+		{key: "LIC-0A1B-2C3D", want: DefaultPrefix, wantOk: true},
+		{key: "L1C-0A1B-2C3D", want: DefaultPrefix, wantOk: true},
+		{key: "ACMES-0A1B", wantOk: false},
+		{key: "OTHER-0A1B", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok := MatchPrefix(tt.key, prefixes)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("MatchPrefix(%q) = %q, %v; want %q, %v", tt.key, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestKeySpecValidate(t *testing.T) {
+	tests := []struct {
+		spec KeySpec
+		ok   bool
+	}{
+		{DefaultKeySpec, true},
+		{KeySpec{Prefix: "XY", Bytes: 10, GroupSize: 3}, true},
+		{KeySpec{Prefix: "ABCDEFGH", Bytes: 32, GroupSize: 8}, true},
+		{KeySpec{Prefix: "X", Bytes: 15, GroupSize: 4}, false},
+		{KeySpec{Prefix: "ABCDEFGHI", Bytes: 15, GroupSize: 4}, false},
+		{KeySpec{Prefix: "acme", Bytes: 15, GroupSize: 4}, false},
+		{KeySpec{Prefix: "AC1", Bytes: 15, GroupSize: 4}, false},
+		{KeySpec{Prefix: "ACME", Bytes: 9, GroupSize: 4}, false},
+		{KeySpec{Prefix: "ACME", Bytes: 33, GroupSize: 4}, false},
+		{KeySpec{Prefix: "ACME", Bytes: 15, GroupSize: 2}, false},
+		{KeySpec{Prefix: "ACME", Bytes: 15, GroupSize: 9}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.spec.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%+v.Validate() = %v, want ok %v", tt.spec, err, tt.ok)
+		}
+	}
+}
+
+func TestGenerateKeyGroups(t *testing.T) {
+	key, err := GenerateKey(KeySpec{Prefix: "ACME", Bytes: 10, GroupSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 bytes are 16 symbols: five groups of three, one of one, and the
+	// check group
+	groups := strings.Split(key, "-")
+	var sizes []int
+	for _, g := range groups[1:] {
+		sizes = append(sizes, len(g))
+	}
+	if groups[0] != "ACME" || !slices.Equal(sizes, []int{3, 3, 3, 3, 3, 1, checkLen}) {
+		t.Errorf("key %q has groups of %v after its prefix", key, sizes)
+	}
+}
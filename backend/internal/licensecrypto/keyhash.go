@@ -0,0 +1,24 @@
+package licensecrypto
+
+import (
+	"strings"
+
+	"github.com/alexedwards/argon2id"
+)
+
+// HashKey returns the Argon2id PHC string stored for a license key. Like the
+// lookup digest it is taken over the normalized key, so every spelling that
+// finds a license also verifies against it.
+func HashKey(key string) (string, error) {
+	return argon2id.CreateHash(NormalizeKey(key), argon2id.DefaultParams)
+}
+
+// VerifyKey checks a presented key against a stored PHC string. normalized
+// tells whether the PHC was created by HashKey; older hashes were taken over
+// the key exactly as issued and only match input typed the same way.
+func VerifyKey(key, phc string, normalized bool) (bool, error) {
+	if normalized {
+		return argon2id.ComparePasswordAndHash(NormalizeKey(key), phc)
+	}
+	return argon2id.ComparePasswordAndHash(strings.TrimSpace(key), phc)
+}
@@ -0,0 +1,62 @@
+package licensecrypto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/argon2id"
+)
+
+func TestVerifyKeyNormalized(t *testing.T) {
+	key := testKey("ACME")
+	phc, err := HashKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"as issued", key, true},
+		{"lower case", strings.ToLower(key), true},
+		{"no separators", strings.ReplaceAll(key, "-", ""), true},
+		{"O for 0", strings.ReplaceAll(key, "0", "O"), true},
+		{"other key", testKey("ACMF"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := VerifyKey(tt.input, phc, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("VerifyKey(%q) = %v, want %v", tt.input, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyKeyLegacyHash(t *testing.T) {
+	key := testKey(DefaultPrefix)
+	// hashes created before normalization cover the key as issued
+	phc, err := HashKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VerifyKey(key, phc, false); ok {
+		t.Error("a normalized hash verified as a legacy hash")
+	}
+
+	legacy, err := argon2id.CreateHash(key, argon2id.DefaultParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyKey(" "+key+" ", legacy, false); err != nil || !ok {
+		t.Errorf("VerifyKey(as issued) = %v, %v", ok, err)
+	}
+	if ok, _ := VerifyKey(strings.ToLower(key), legacy, false); ok {
+		t.Error("a legacy hash matched a differently typed key")
+	}
+}
@@ -0,0 +1,303 @@
+package licensecrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Signing algorithms, named as in JOSE.
+const (
+	AlgEdDSA = "EdDSA"
+	AlgES256 = "ES256"
+	AlgRS256 = "RS256"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing keys.
+const minRSABits = 2048
+
+// SigningKey is a private key together with the one algorithm it may be
+// used with. ID is the RFC 7638 thumbprint of its public key.
+type SigningKey struct {
+	ID     string
+	Alg    string
+	Signer crypto.Signer
+}
+
+// Public returns the public half of the key.
+func (k SigningKey) Public() crypto.PublicKey {
+	return k.Signer.Public()
+}
+
+// NewSigningKey derives algorithm and key id from the key type: Ed25519
+// keys sign EdDSA, P-256 keys ES256 and RSA keys of at least 2048 bits
+// RS256.
+func NewSigningKey(priv crypto.Signer) (SigningKey, error) {
+	var alg string
+	switch k := priv.(type) {
+	case ed25519.PrivateKey:
+		if len(k) != ed25519.PrivateKeySize {
+			return SigningKey{}, errors.New("invalid ed25519 private key size")
+		}
+		alg = AlgEdDSA
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return SigningKey{}, errors.New("ecdsa keys must use P-256")
+		}
+		alg = AlgES256
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSABits {
+			return SigningKey{}, fmt.Errorf("rsa keys must have at least %d bits", minRSABits)
+		}
+		alg = AlgRS256
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported key type %T", priv)
+	}
+
+	jwk, err := PublicJWK(priv.Public())
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return SigningKey{ID: jwk.Thumbprint(), Alg: alg, Signer: priv}, nil
+}
+
+// ParsePrivateKey decodes a base64 signing key. Raw 64 byte Ed25519 keys
+// are accepted as before; other key types are PKCS #8 DER.
+func ParsePrivateKey(b64 string) (crypto.Signer, error) {
+	der, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, err
+	}
+	if len(der) == ed25519.PrivateKeySize {
+		return ed25519.PrivateKey(der), nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return signer, nil
+}
+
+// Keyring holds the server's signing keys. The first key added for an
+// algorithm signs with it; every key stays available for verification.
+type Keyring struct {
+	keys   []SigningKey
+	public KeySet
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{}
+}
+
+// Add puts a key on the ring. Adding the same key twice is a no-op.
+func (r *Keyring) Add(priv crypto.Signer) (SigningKey, error) {
+	key, err := NewSigningKey(priv)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	if existing, ok := r.Lookup(key.ID); ok {
+		return existing, nil
+	}
+	r.keys = append(r.keys, key)
+	r.public = append(r.public, VerificationKey{ID: key.ID, Alg: key.Alg, Key: key.Public()})
+	return key, nil
+}
+
+// Public returns the verification keys of the ring.
+func (r *Keyring) Public() KeySet {
+	return r.public
+}
+
+// Signer returns the key that signs with alg.
+func (r *Keyring) Signer(alg string) (SigningKey, bool) {
+	for _, k := range r.keys {
+		if k.Alg == alg {
+			return k, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+// Lookup finds a key by id.
+func (r *Keyring) Lookup(kid string) (SigningKey, bool) {
+	for _, k := range r.keys {
+		if k.ID == kid {
+			return k, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+// JWKS returns the public keys of the ring as a JSON Web Key Set.
+func (r *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(r.keys))}
+	for _, k := range r.keys {
+		jwk, err := PublicJWK(k.Public())
+		if err != nil {
+			continue
+		}
+		jwk.Kid, jwk.Alg, jwk.Use = k.ID, k.Alg, "sig"
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// JWK is a public JSON Web Key (RFC 7517) of one of the supported types.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// KeySet converts the supported keys of a JWKS to a key set. Keys without
+// an id get their thumbprint, keys without an algorithm the one their type
+// implies.
+func (s JWKSet) KeySet() KeySet {
+	set := make(KeySet, 0, len(s.Keys))
+	for _, jwk := range s.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+
+		k := VerificationKey{ID: jwk.Kid, Alg: jwk.Alg, Key: pub}
+		if k.ID == "" {
+			k.ID = jwk.Thumbprint()
+		}
+		if k.Alg == "" {
+			k.Alg = jwkAlg(jwk)
+		}
+		set = append(set, k)
+	}
+	return set
+}
+
+// PublicJWK converts an Ed25519, P-256 or RSA public key to a JWK.
+func PublicJWK(pub crypto.PublicKey) (JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: b64(k)}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return JWK{}, errors.New("ecdsa keys must use P-256")
+		}
+		ecdh, err := k.ECDH()
+		if err != nil {
+			return JWK{}, err
+		}
+		raw := ecdh.Bytes()
+		// uncompressed point: 0x04 || X || Y
+		return JWK{Kty: "EC", Crv: "P-256", X: b64(raw[1:33]), Y: b64(raw[33:])}, nil
+	case *rsa.PublicKey:
+		return JWK{Kty: "RSA", N: b64(k.N.Bytes()), E: b64(big.NewInt(int64(k.E)).Bytes())}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", pub)
+	}
+}
+
+// PublicKey converts the JWK back to an Ed25519, P-256 or RSA public key.
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+
+	switch {
+	case j.Kty == "OKP" && j.Crv == "Ed25519":
+		x, err := b64(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 jwk")
+		}
+		return ed25519.PublicKey(x), nil
+	case j.Kty == "EC" && j.Crv == "P-256":
+		x, errX := b64(j.X)
+		y, errY := b64(j.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid P-256 jwk")
+		}
+		raw := append(append([]byte{4}, x...), y...)
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), raw)
+	case j.Kty == "RSA":
+		n, errN := b64(j.N)
+		e, errE := b64(j.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid rsa jwk")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("rsa keys must have at least %d bits", minRSABits)
+		}
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported jwk type %s %s", j.Kty, j.Crv)
+	}
+}
+
+// jwkAlg is the algorithm a key of the JWK's type signs with here.
+func jwkAlg(j JWK) string {
+	switch j.Kty {
+	case "OKP":
+		return AlgEdDSA
+	case "EC":
+		return AlgES256
+	default:
+		return AlgRS256
+	}
+}
+
+// Thumbprint is the RFC 7638 thumbprint of the key: a SHA-256 over its
+// required members in lexicographic order.
+func (j JWK) Thumbprint() string {
+	var members any
+	switch j.Kty {
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+	default:
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	}
+
+	raw, _ := json.Marshal(members)
+	sum := sha256.Sum256(raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
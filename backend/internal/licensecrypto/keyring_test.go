@@ -0,0 +1,84 @@
+package licensecrypto
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+)
+
+// TestThumbprintRFC8037 checks the Ed25519 thumbprint against the example
+// in RFC 8037, appendix A.3.
+func TestThumbprintRFC8037(t *testing.T) {
+	jwk := JWK{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}
+
+	const want = "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+	if got := jwk.Thumbprint(); got != want {
+		t.Errorf("Thumbprint() = %q, want %q", got, want)
+	}
+
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DeviceKeyThumbprint(pub.(ed25519.PublicKey)); got != want {
+		t.Errorf("DeviceKeyThumbprint() = %q, want %q", got, want)
+	}
+}
+
+// TestJWKSRoundTrip publishes a keyring as a JWKS and expects a client
+// reading it back to end up with the ring's verification keys.
+func TestJWKSRoundTrip(t *testing.T) {
+	keys := newTestKeyring(t)
+
+	raw, err := json.Marshal(keys.JWKS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set JWKSet
+	if err := json.Unmarshal(raw, &set); err != nil {
+		t.Fatal(err)
+	}
+
+	got, want := set.KeySet(), keys.Public()
+	if len(got) != len(want) {
+		t.Fatalf("got %d keys, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Alg != want[i].Alg {
+			t.Errorf("key %d = %s %s, want %s %s", i, got[i].ID, got[i].Alg, want[i].ID, want[i].Alg)
+		}
+		jwk, err := PublicJWK(got[i].Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if jwk.Thumbprint() != want[i].ID {
+			t.Errorf("key %d does not match its id", i)
+		}
+	}
+
+	// pinned keys get the same ids as published ones
+	pinned, err := NewKeySet(keys.Public()[0].Key, keys.Public()[1].Key, keys.Public()[2].Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if pinned[i].ID != want[i].ID || pinned[i].Alg != want[i].Alg {
+			t.Errorf("pinned key %d = %s %s, want %s %s", i, pinned[i].ID, pinned[i].Alg, want[i].ID, want[i].Alg)
+		}
+	}
+}
+
+func TestJWKSetSkipsUnusableKeys(t *testing.T) {
+	set := JWKSet{Keys: []JWK{
+		{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo", Use: "enc"},
+		{Kty: "OKP", Crv: "X25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		{Kty: "OKP", Crv: "Ed25519", X: "too short"},
+		{Kty: "RSA", N: "AQAB", E: "AQAB"},
+		{Kty: "OKP", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+	}}
+
+	got := set.KeySet()
+	if len(got) != 1 || got[0].Alg != AlgEdDSA || got[0].ID != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("KeySet() = %+v, want only the Ed25519 signing key", got)
+	}
+}
@@ -0,0 +1,179 @@
+package licensecrypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// LicenseFilePEMType is the armor type of PEM encoded license files.
+const LicenseFilePEMType = "CLAVE LICENSE"
+
+// licenseFileVersion is the version field of the license file envelope.
+const licenseFileVersion = 1
+
+var (
+	ErrLicenseFileExpired = errors.New("license file has expired")
+	ErrDeviceNotLicensed  = errors.New("device is not covered by the license file")
+)
+
+// LicenseFile is the content of a license file, a signed document that
+// lets a deployment check its license without reaching the server.
+type LicenseFile struct {
+	LicenseID      int32     `json:"licenseId"`
+	ProductID      int32     `json:"productId"`
+	ProductName    string    `json:"productName,omitempty"`
+	Features       []string  `json:"features,omitempty"`
+	MaxActivations int32     `json:"maxActivations,omitempty"`
+	IssuedAt       time.Time `json:"issuedAt"`
+	// ExpiresAt is nil for perpetual licenses.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// HWIDs lists the devices the file is valid on; empty means any.
+	HWIDs    []string          `json:"hwids,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Check reports whether the file covers the device at the given time.
+func (f LicenseFile) Check(hwid string, now time.Time) error {
+	if f.ExpiresAt != nil && now.After(*f.ExpiresAt) {
+		return ErrLicenseFileExpired
+	}
+	if len(f.HWIDs) > 0 && !slices.Contains(f.HWIDs, hwid) {
+		return ErrDeviceNotLicensed
+	}
+	return nil
+}
+
+// licenseFileEnvelope is the on-disk form: the payload is kept as signed
+// bytes so verification does not depend on JSON canonicalization.
+type licenseFileEnvelope struct {
+	Version   int    `json:"version"`
+	Alg       string `json:"alg"`
+	Kid       string `json:"kid"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// SignLicenseFile signs f with key and returns the JSON license file, or
+// its PEM armored form if armored is set.
+func SignLicenseFile(f LicenseFile, key SigningKey, armored bool) ([]byte, error) {
+	payload, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := signMessage(key, licenseFileMessage(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := json.MarshalIndent(licenseFileEnvelope{
+		Version:   licenseFileVersion,
+		Alg:       key.Alg,
+		Kid:       key.ID,
+		Payload:   base64.RawURLEncoding.EncodeToString(payload),
+		Signature: base64.RawURLEncoding.EncodeToString(sig),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if armored {
+		return pem.EncodeToMemory(&pem.Block{Type: LicenseFilePEMType, Bytes: out}), nil
+	}
+	return append(out, '\n'), nil
+}
+
+// VerifyLicenseFile checks the signature of a JSON or PEM license file
+// against the given public keys, picked by key id, and returns its content.
+// It does not check expiry or devices; see LicenseFile.Check.
+func VerifyLicenseFile(data []byte, keys ...crypto.PublicKey) (LicenseFile, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != LicenseFilePEMType {
+			return LicenseFile{}, fmt.Errorf("unexpected PEM block %q", block.Type)
+		}
+		data = block.Bytes
+	}
+
+	var env licenseFileEnvelope
+	if err := json.Unmarshal(bytes.TrimSpace(data), &env); err != nil {
+		return LicenseFile{}, errors.New("malformed license file")
+	}
+	if env.Version != licenseFileVersion {
+		return LicenseFile{}, fmt.Errorf("unsupported license file version %d", env.Version)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return LicenseFile{}, errors.New("malformed license file")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(env.Signature)
+	if err != nil {
+		return LicenseFile{}, errors.New("malformed license file")
+	}
+
+	var pub crypto.PublicKey
+	for _, k := range keys {
+		if jwk, err := PublicJWK(k); err == nil && jwk.Thumbprint() == env.Kid {
+			pub = k
+			break
+		}
+	}
+	if pub == nil {
+		return LicenseFile{}, errors.New("license file is signed with an unknown key")
+	}
+
+	if !verifyMessage(pub, env.Alg, licenseFileMessage(payload), sig) {
+		return LicenseFile{}, ErrInvalidSignature
+	}
+
+	var f LicenseFile
+	if err := json.Unmarshal(payload, &f); err != nil {
+		return LicenseFile{}, errors.New("malformed license file payload")
+	}
+	return f, nil
+}
+
+// licenseFileMessage separates license file signatures from those over
+// other documents made with the same keys.
+func licenseFileMessage(payload []byte) []byte {
+	return append([]byte("clave-license-file\n"), payload...)
+}
+
+// signMessage signs msg with the key's algorithm. ECDSA signatures are
+// ASN.1 encoded.
+func signMessage(key SigningKey, msg []byte) ([]byte, error) {
+	if key.Alg == AlgEdDSA {
+		return key.Signer.Sign(rand.Reader, msg, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(msg)
+	return key.Signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// verifyMessage checks a signature made by signMessage, insisting that the
+// key type matches alg.
+func verifyMessage(pub crypto.PublicKey, alg string, msg, sig []byte) bool {
+	digest := sha256.Sum256(msg)
+
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		return alg == AlgEdDSA && ed25519.Verify(k, msg, sig)
+	case *ecdsa.PublicKey:
+		return alg == AlgES256 && ecdsa.VerifyASN1(k, digest[:], sig)
+	case *rsa.PublicKey:
+		return alg == AlgRS256 && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	default:
+		return false
+	}
+}
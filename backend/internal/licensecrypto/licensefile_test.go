@@ -0,0 +1,125 @@
+package licensecrypto
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testLicenseFile() LicenseFile {
+	exp := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	return LicenseFile{
+		LicenseID:      42,
+		ProductID:      7,
+		ProductName:    "Clave Pro",
+		Features:       []string{"pro"},
+		MaxActivations: 2,
+		IssuedAt:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt:      &exp,
+		HWIDs:          []string{"device-1", "device-2"},
+		Metadata:       map[string]string{"customer": "ACME"},
+	}
+}
+
+func TestLicenseFileRoundTrip(t *testing.T) {
+	keys := newTestKeyring(t)
+	want, _ := json.Marshal(testLicenseFile())
+
+	for _, alg := range []string{AlgEdDSA, AlgES256, AlgRS256} {
+		for _, armored := range []bool{false, true} {
+			key, _ := keys.Signer(alg)
+			data, err := SignLicenseFile(testLicenseFile(), key, armored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if armored != bytes.HasPrefix(data, []byte("-----BEGIN "+LicenseFilePEMType)) {
+				t.Errorf("%s armored=%v: wrong encoding:\n%s", alg, armored, data)
+			}
+
+			pubs := []crypto.PublicKey{}
+			for _, k := range keys.Public() {
+				pubs = append(pubs, k.Key)
+			}
+			f, err := VerifyLicenseFile(data, pubs...)
+			if err != nil {
+				t.Fatalf("%s armored=%v: %v", alg, armored, err)
+			}
+			if got, _ := json.Marshal(f); !bytes.Equal(got, want) {
+				t.Errorf("%s armored=%v: got %s, want %s", alg, armored, got, want)
+			}
+		}
+	}
+}
+
+func TestVerifyLicenseFileRejects(t *testing.T) {
+	keys := newTestKeyring(t)
+	key, _ := keys.Signer(AlgEdDSA)
+	pub := key.Public()
+	other, _ := newTestEd25519(t)
+
+	data, err := SignLicenseFile(testLicenseFile(), key, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edit := func(change func(env *licenseFileEnvelope)) []byte {
+		var env licenseFileEnvelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			t.Fatal(err)
+		}
+		change(&env)
+		out, _ := json.Marshal(env)
+		return out
+	}
+	raised := testLicenseFile()
+	raised.MaxActivations = 1000
+	raisedPayload, _ := json.Marshal(raised)
+
+	tests := []struct {
+		name string
+		data []byte
+		keys []crypto.PublicKey
+		want error
+	}{
+		{"other key", data, []crypto.PublicKey{other}, nil},
+		{"edited payload", edit(func(env *licenseFileEnvelope) {
+			env.Payload = base64.RawURLEncoding.EncodeToString(raisedPayload)
+		}), []crypto.PublicKey{pub}, ErrInvalidSignature},
+		{"algorithm swapped", edit(func(env *licenseFileEnvelope) { env.Alg = AlgRS256 }), []crypto.PublicKey{pub}, ErrInvalidSignature},
+		{"future version", edit(func(env *licenseFileEnvelope) { env.Version = 2 }), []crypto.PublicKey{pub}, nil},
+		{"not a license file", []byte("hello"), []crypto.PublicKey{pub}, nil},
+		{"other PEM block", []byte("-----BEGIN PUBLIC KEY-----\nAAAA\n-----END PUBLIC KEY-----\n"), []crypto.PublicKey{pub}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyLicenseFile(tt.data, tt.keys...)
+			if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("VerifyLicenseFile() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLicenseFileCheck(t *testing.T) {
+	f := testLicenseFile()
+	before := f.ExpiresAt.Add(-time.Hour)
+
+	if err := f.Check("device-2", before); err != nil {
+		t.Errorf("Check() = %v", err)
+	}
+	if err := f.Check("device-3", before); !errors.Is(err, ErrDeviceNotLicensed) {
+		t.Errorf("Check() on another device = %v, want %v", err, ErrDeviceNotLicensed)
+	}
+	if err := f.Check("device-1", f.ExpiresAt.Add(time.Second)); !errors.Is(err, ErrLicenseFileExpired) {
+		t.Errorf("Check() after expiry = %v, want %v", err, ErrLicenseFileExpired)
+	}
+
+	f.HWIDs, f.ExpiresAt = nil, nil
+	if err := f.Check("anything", before.AddDate(100, 0, 0)); err != nil {
+		t.Errorf("Check() of an unrestricted file = %v", err)
+	}
+}
@@ -1,11 +1,61 @@
 package licensecrypto
 
-import "strings"
+import (
+	"strings"
+	"unicode"
+)
 
+// NormalizeKey canonicalizes a license key before it is digested. Case and
+// any separators (dashes, spaces, underscores, dots, braces, ...) are
+// ignored, so keys imported from legacy systems match regardless of how
+// they were grouped or typed. Apart from keys in the original LIC base32
+// format, which are digested as-is, the commonly confused O, I and L are
+// read as 0, 1 and 1.
 func NormalizeKey(s string) string {
-	s = strings.TrimSpace(s)
-	s = strings.ToUpper(s)
-	s = strings.ReplaceAll(s, "-", "")
-	s = strings.ReplaceAll(s, " ", "")
-	return s
+	n := compact(s)
+	if isLegacyKey(n) {
+		return n
+	}
+	return canonicalize(n)
+}
+
+// compact upper-cases s and strips everything but letters and digits.
+func compact(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range strings.ToUpper(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// canonicalize applies Crockford's decoding rules to a compacted key.
+func canonicalize(n string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case 'O':
+			return '0'
+		case 'I', 'L':
+			return '1'
+		}
+		return r
+	}, n)
+}
+
+// isLegacyKey reports whether a compacted key has the shape of the original
+// key format: LIC followed by 24 symbols of RFC 4648 base32 and no check
+// group.
+func isLegacyKey(n string) bool {
+	body, ok := strings.CutPrefix(n, DefaultPrefix)
+	if !ok || len(body) != 24 {
+		return false
+	}
+	for _, r := range body {
+		if !(r >= 'A' && r <= 'Z' || r >= '2' && r <= '7') {
+			return false
+		}
+	}
+	return true
 }
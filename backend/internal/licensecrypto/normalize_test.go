@@ -0,0 +1,121 @@
+package licensecrypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// testKey builds a native key around a fixed body containing the digits 0
+// and 1, which Crockford's rules let users type as O, I or L.
+func testKey(prefix string) string {
+	raw := "0A1B2C3D4E5F6G7H8J9K"
+	return formatKey(prefix, raw, 4) + "-" + checksum(canonicalize(prefix+raw))
+}
+
+func TestNormalizeKeyVariants(t *testing.T) {
+	key := testKey(DefaultPrefix)
+	want := NormalizeKey(key)
+
+	variants := map[string]string{
+		"lower case":        strings.ToLower(key),
+		"no separators":     strings.ReplaceAll(key, "-", ""),
+		"spaces":            strings.ReplaceAll(key, "-", " "),
+		"mixed separators":  strings.Replace(strings.Replace(key, "-", "_", 1), "-", ".", 1),
+		"surrounding space": "  " + key + "\n",
+		"O for 0":           strings.ReplaceAll(key, "0", "O"),
+		"I for 1":           strings.ReplaceAll(key, "1", "I"),
+		"l for 1":           strings.ReplaceAll(strings.ToLower(key), "1", "l"),
+	}
+	for name, v := range variants {
+		t.Run(name, func(t *testing.T) {
+			if got := NormalizeKey(v); got != want {
+				t.Errorf("NormalizeKey(%q) = %q, want %q", v, got, want)
+			}
+
+			secret := []byte("secret")
+			if !bytes.Equal(LookupDigest(secret, v), LookupDigest(secret, key)) {
+				t.Errorf("LookupDigest(%q) differs from the digest of %q", v, key)
+			}
+			if err := ValidateKeyFormat(v); err != nil {
+				t.Errorf("ValidateKeyFormat(%q) = %v", v, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeKeyLegacy(t *testing.T) {
+	// legacy keys are RFC 4648 base32, where O and I are distinct symbols
+	key := "LIC-ABCD-EFGH-IJKL-MNOP-QRST-UVWX"
+
+	if got, want := NormalizeKey(key), "LICABCDEFGHIJKLMNOPQRSTUVWX"; got != want {
+		t.Errorf("NormalizeKey(%q) = %q, want %q", key, got, want)
+	}
+	if got := NormalizeKey(strings.ToLower(strings.ReplaceAll(key, "-", ""))); got != NormalizeKey(key) {
+		t.Errorf("lower case legacy key normalized to %q", got)
+	}
+	if err := ValidateKeyFormat(key); err != nil {
+		t.Errorf("ValidateKeyFormat(%q) = %v", key, err)
+	}
+}
+
+func TestValidateKeyFormat(t *testing.T) {
+	key := testKey("ACME")
+
+	// swap two adjacent, distinct symbols of the random part
+	b := []byte(key)
+	b[5], b[6] = b[6], b[5]
+	transposed := string(b)
+
+	// replace the last symbol of the check group with another one
+	last := key[len(key)-1]
+	typo := key[:len(key)-1] + string(crockford[(strings.IndexByte(crockford, last)+1)%len(crockford)])
+
+	tests := []struct {
+		name string
+		key  string
+		want error
+	}{
+		{"valid", key, nil},
+		{"valid lower case", strings.ToLower(key), nil},
+		{"mistyped check group", typo, ErrKeyChecksum},
+		{"transposed symbols", transposed, ErrKeyChecksum},
+		{"missing check group", key[:len(key)-5], ErrKeyChecksum},
+		{"not in alphabet", "ACME-UUUU-UUUU-UUUU", ErrMalformedKey},
+		{"prefix only", "ACME", ErrMalformedKey},
+		{"empty", " - ", ErrMalformedKey},
+		{"foreign prefix", "OTHER-1234-5678", ErrMalformedKey},
+		{"no prefix", "hello", ErrMalformedKey},
+		{"digits only", "12345", ErrMalformedKey},
+		{"default prefix", testKey(DefaultPrefix), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateKeyFormat(tt.key, "ACME"); err != tt.want {
+				t.Errorf("ValidateKeyFormat(%q) = %v, want %v", tt.key, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateKeyValidates(t *testing.T) {
+	specs := []KeySpec{
+		DefaultKeySpec,
+		{Prefix: "ACME", Bytes: 10, GroupSize: 3},
+		{Prefix: "XY", Bytes: 32, GroupSize: 8},
+	}
+	for _, spec := range specs {
+		for range 20 {
+			key, err := GenerateKey(spec)
+			if err != nil {
+				t.Fatalf("GenerateKey(%+v): %v", spec, err)
+			}
+			if !strings.HasPrefix(key, spec.Prefix+"-") {
+				t.Errorf("key %q does not start with %s-", key, spec.Prefix)
+			}
+			if err := ValidateKeyFormat(key, spec.Prefix); err != nil {
+				t.Errorf("ValidateKeyFormat(%q) = %v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package licensecrypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+const pasetoV4Header = "v4.public."
+
+// pasetoTimeClaims are the registered claims PASETO encodes as RFC 3339
+// strings rather than JWT's numeric dates.
+var pasetoTimeClaims = []string{"exp", "nbf", "iat"}
+
+// pasetoFooter names the signing key, as the PASETO spec recommends for
+// key ids.
+type pasetoFooter struct {
+	Kid string `json:"kid"`
+}
+
+// pasetoV4Codec implements PASETO v4.public tokens: Ed25519 over the
+// pre-authentication encoding of header, payload, footer and an unused
+// implicit assertion.
+type pasetoV4Codec struct{}
+
+func (pasetoV4Codec) supports(alg string) bool {
+	return alg == AlgEdDSA
+}
+
+func (pasetoV4Codec) encode(claims *LicenseClaims, key SigningKey) (string, error) {
+	priv, ok := key.Signer.(ed25519.PrivateKey)
+	if !ok {
+		return "", errors.New("v4.public tokens require an ed25519 key")
+	}
+
+	footer, err := json.Marshal(pasetoFooter{Kid: key.ID})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err = convertTimeClaims(payload, func(v json.Number) (any, error) {
+		secs, err := v.Int64()
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(secs, 0).UTC().Format(time.RFC3339), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return signV4Public(priv, payload, footer, nil), nil
+}
+
+func (pasetoV4Codec) decode(token string, keys KeySet) (*LicenseClaims, error) {
+	payload, sig, footer, err := splitV4Public(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var f pasetoFooter
+	if len(footer) > 0 {
+		if err := json.Unmarshal(footer, &f); err != nil {
+			return nil, errors.New("malformed token footer")
+		}
+	}
+	key, err := keys.find(f.Kid, AlgEdDSA)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("v4.public tokens require an ed25519 key")
+	}
+
+	if !verifyV4Public(pub, payload, sig, footer, nil) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	payload, err = convertTimeClaims(payload, func(v json.Number) (any, error) {
+		t, err := time.Parse(time.RFC3339, v.String())
+		if err != nil {
+			return nil, err
+		}
+		return t.Unix(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &LicenseClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// signV4Public returns the v4.public token of payload and footer, signed
+// together with the implicit assertion, which is not part of the token.
+func signV4Public(priv ed25519.PrivateKey, payload, footer, implicit []byte) string {
+	sig := ed25519.Sign(priv, pae([]byte(pasetoV4Header), payload, footer, implicit))
+
+	token := pasetoV4Header + base64.RawURLEncoding.EncodeToString(append(slices.Clip(payload), sig...))
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+// splitV4Public decodes the payload, signature and footer of a v4.public
+// token without verifying it.
+func splitV4Public(token string) (payload, sig, footer []byte, err error) {
+	body, ok := strings.CutPrefix(token, pasetoV4Header)
+	if !ok {
+		return nil, nil, nil, errors.New("not a v4.public token")
+	}
+
+	if i := strings.IndexByte(body, '.'); i >= 0 {
+		footer, err = base64.RawURLEncoding.DecodeString(body[i+1:])
+		if err != nil {
+			return nil, nil, nil, errors.New("malformed token footer")
+		}
+		body = body[:i]
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(raw) < ed25519.SignatureSize {
+		return nil, nil, nil, errors.New("malformed token")
+	}
+	return raw[:len(raw)-ed25519.SignatureSize], raw[len(raw)-ed25519.SignatureSize:], footer, nil
+}
+
+// verifyV4Public checks the signature of a token split by splitV4Public.
+func verifyV4Public(pub ed25519.PublicKey, payload, sig, footer, implicit []byte) bool {
+	return ed25519.Verify(pub, pae([]byte(pasetoV4Header), payload, footer, implicit), sig)
+}
+
+// convertTimeClaims rewrites the exp, nbf and iat members of a JSON object.
+// Values are handed to convert as json.Number whether they were numbers or
+// strings.
+func convertTimeClaims(payload []byte, convert func(json.Number) (any, error)) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	for _, name := range pasetoTimeClaims {
+		var n json.Number
+		switch v := m[name].(type) {
+		case nil:
+			continue
+		case json.Number:
+			n = v
+		case string:
+			n = json.Number(v)
+		default:
+			return nil, errors.New("invalid " + name + " claim")
+		}
+
+		converted, err := convert(n)
+		if err != nil {
+			return nil, errors.New("invalid " + name + " claim")
+		}
+		m[name] = converted
+	}
+
+	return json.Marshal(m)
+}
+
+// pae is PASETO's pre-authentication encoding.
+func pae(pieces ...[]byte) []byte {
+	le64 := func(n int) []byte {
+		return binary.LittleEndian.AppendUint64(nil, uint64(n)&^(1<<63))
+	}
+
+	out := le64(len(pieces))
+	for _, p := range pieces {
+		out = append(out, le64(len(p))...)
+		out = append(out, p...)
+	}
+	return out
+}
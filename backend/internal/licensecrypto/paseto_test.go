@@ -0,0 +1,97 @@
+package licensecrypto
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+)
+
+// pasetoV4Vectors are the v4.public test vectors 4-S-1 to 4-S-3 of the
+// PASETO specification, from v4.json in paseto-standard/test-vectors.
+var pasetoV4Vectors = []struct {
+	name     string
+	seed     string
+	pub      string
+	token    string
+	payload  string
+	footer   string
+	implicit string
+}{
+	{
+		name:    "4-S-1",
+		seed:    "b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774",
+		pub:     "1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2",
+		token:   "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA",
+		payload: `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`,
+	},
+	{
+		name:    "4-S-2",
+		seed:    "b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774",
+		pub:     "1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2",
+		token:   "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9v3Jt8mx_TdM2ceTGoqwrh4yDFn0XsHvvV_D0DtwQxVrJEBMl0F2caAdgnpKlt4p7xBnx1HcO-SPo8FPp214HDw.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		payload: `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`,
+		footer:  `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`,
+	},
+	{
+		name:     "4-S-3",
+		seed:     "b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774",
+		pub:      "1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2",
+		token:    "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9NPWciuD3d0o5eXJXG5pJy-DiVEoyPYWs1YSTwWHNJq6DZD3je5gf-0M4JR9ipdUSJbIovzmBECeaWmaqcaP0DQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		payload:  `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`,
+		footer:   `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`,
+		implicit: `{"test-vector":"4-S-3"}`,
+	},
+}
+
+func TestPasetoV4Vectors(t *testing.T) {
+	for _, v := range pasetoV4Vectors {
+		t.Run(v.name, func(t *testing.T) {
+			seed, _ := hex.DecodeString(v.seed)
+			priv := ed25519.NewKeyFromSeed(seed)
+			pub, _ := hex.DecodeString(v.pub)
+			if !priv.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(pub)) {
+				t.Fatal("public key does not match the seed")
+			}
+
+			got := signV4Public(priv, []byte(v.payload), []byte(v.footer), []byte(v.implicit))
+			if got != v.token {
+				t.Errorf("signV4Public() = %s\nwant %s", got, v.token)
+			}
+
+			payload, sig, footer, err := splitV4Public(v.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(payload) != v.payload || string(footer) != v.footer {
+				t.Errorf("splitV4Public() = %q, %q; want %q, %q", payload, footer, v.payload, v.footer)
+			}
+			if !verifyV4Public(pub, payload, sig, footer, []byte(v.implicit)) {
+				t.Error("verifyV4Public() rejects the vector")
+			}
+			if verifyV4Public(pub, payload, sig, footer, []byte("other")) {
+				t.Error("verifyV4Public() accepts another implicit assertion")
+			}
+		})
+	}
+}
+
+// TestPAE checks the examples of the PASETO specification.
+func TestPAE(t *testing.T) {
+	tests := []struct {
+		name   string
+		pieces [][]byte
+		want   []byte
+	}{
+		{"no pieces", nil, []byte("\x00\x00\x00\x00\x00\x00\x00\x00")},
+		{"empty piece", [][]byte{{}}, []byte("\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")},
+		{"test", [][]byte{[]byte("test")}, []byte("\x01\x00\x00\x00\x00\x00\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00test")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pae(tt.pieces...); !bytes.Equal(got, tt.want) {
+				t.Errorf("pae() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package licensecrypto
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+var ErrInvalidProof = errors.New("device proof is invalid")
+
+// DeviceKeyThumbprint is the RFC 7638 JWK thumbprint of a device's Ed25519
+// public key, as carried in the cnf claim of tokens bound to that device.
+func DeviceKeyThumbprint(pub ed25519.PublicKey) string {
+	// members in lexicographic order, no whitespace, as RFC 7638 requires
+	jwk := `{"crv":"Ed25519","kty":"OKP","x":"` + base64.RawURLEncoding.EncodeToString(pub) + `"}`
+	sum := sha256.Sum256([]byte(jwk))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// DeviceProofMessage is what a device signs to prove it holds the key a
+// token is bound to: a single use nonce issued by the server and the token
+// being presented.
+func DeviceProofMessage(nonce, token string) []byte {
+	return []byte("clave-pop\n" + nonce + "\n" + token)
+}
+
+// RebindProofMessage is what a device signs with the key of its activation
+// to move that activation to a new device id, and optionally a new key,
+// after some of its hardware changed.
+func RebindProofMessage(nonce, deviceID string, newKey ed25519.PublicKey) []byte {
+	thumbprint := ""
+	if newKey != nil {
+		thumbprint = DeviceKeyThumbprint(newKey)
+	}
+	return []byte("clave-rebind\n" + nonce + "\n" + deviceID + "\n" + thumbprint)
+}
+
+// VerifyDeviceProof checks a base64 (standard or url-safe) proof signature.
+func VerifyDeviceProof(pub ed25519.PublicKey, nonce, token, proof string) error {
+	return verifyProof(pub, DeviceProofMessage(nonce, token), proof)
+}
+
+// VerifyRebindProof checks a base64 (standard or url-safe) signature over
+// RebindProofMessage by the activation's current key pub.
+func VerifyRebindProof(pub ed25519.PublicKey, nonce, deviceID string, newKey ed25519.PublicKey, proof string) error {
+	return verifyProof(pub, RebindProofMessage(nonce, deviceID, newKey), proof)
+}
+
+func verifyProof(pub ed25519.PublicKey, msg []byte, proof string) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return errors.New("invalid ed25519 public key size")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(proof)
+	if err != nil {
+		sig, err = base64.StdEncoding.DecodeString(proof)
+	}
+	if err != nil || !ed25519.Verify(pub, msg, sig) {
+		return ErrInvalidProof
+	}
+	return nil
+}
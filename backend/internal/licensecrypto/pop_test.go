@@ -0,0 +1,114 @@
+package licensecrypto
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestVerifyRebindProof(t *testing.T) {
+	oldPub, oldPriv := newTestEd25519(t)
+	newPub, _ := newTestEd25519(t)
+	const nonce = "bm9uY2Utbm9uY2Utbm9uY2U"
+
+	sign := func(priv ed25519.PrivateKey, deviceID string, key ed25519.PublicKey) string {
+		return base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, RebindProofMessage(nonce, deviceID, key)))
+	}
+	proof := sign(oldPriv, "device-2", newPub)
+
+	if err := VerifyRebindProof(oldPub, nonce, "device-2", newPub, proof); err != nil {
+		t.Fatalf("VerifyRebindProof() = %v", err)
+	}
+
+	_, otherPriv := newTestEd25519(t)
+	tests := []struct {
+		name     string
+		pub      ed25519.PublicKey
+		nonce    string
+		deviceID string
+		newKey   ed25519.PublicKey
+		proof    string
+	}{
+		{"signed by new key", newPub, nonce, "device-2", newPub, sign(otherPriv, "device-2", newPub)},
+		{"other device id", oldPub, nonce, "device-3", newPub, proof},
+		{"other new key", oldPub, nonce, "device-2", oldPub, proof},
+		{"no new key", oldPub, nonce, "device-2", nil, proof},
+		{"other nonce", oldPub, "b3RoZXItbm9uY2Utbm9uY2U", "device-2", newPub, proof},
+		{"not base64", oldPub, nonce, "device-2", newPub, "!!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyRebindProof(tt.pub, tt.nonce, tt.deviceID, tt.newKey, tt.proof)
+			if !errors.Is(err, ErrInvalidProof) {
+				t.Errorf("VerifyRebindProof() = %v, want ErrInvalidProof", err)
+			}
+		})
+	}
+}
+
+func TestRebindProofDiffersFromDeviceProof(t *testing.T) {
+	pub, priv := newTestEd25519(t)
+	const nonce = "bm9uY2Utbm9uY2Utbm9uY2U"
+
+	// a proof presented with a token must not double as a rebind proof
+	sig := base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, DeviceProofMessage(nonce, "device-2")))
+	if err := VerifyRebindProof(pub, nonce, "device-2", nil, sig); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyRebindProof() = %v, want ErrInvalidProof", err)
+	}
+}
+
+func TestDeviceKeyThumbprint(t *testing.T) {
+	// RFC 8037, appendix A.3
+	x, err := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+	if got := DeviceKeyThumbprint(ed25519.PublicKey(x)); got != want {
+		t.Errorf("DeviceKeyThumbprint() = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyDeviceProof(t *testing.T) {
+	pub, priv := newTestEd25519(t)
+	other, _ := newTestEd25519(t)
+	const nonce = "bm9uY2Utbm9uY2Utbm9uY2U"
+	const token = "header.payload.signature"
+
+	sig := ed25519.Sign(priv, DeviceProofMessage(nonce, token))
+	for name, proof := range map[string]string{
+		"base64url": base64.RawURLEncoding.EncodeToString(sig),
+		"base64":    base64.StdEncoding.EncodeToString(sig),
+	} {
+		if err := VerifyDeviceProof(pub, nonce, token, proof); err != nil {
+			t.Errorf("VerifyDeviceProof(%s) = %v", name, err)
+		}
+	}
+
+	proof := base64.RawURLEncoding.EncodeToString(sig)
+	tests := []struct {
+		name  string
+		pub   ed25519.PublicKey
+		nonce string
+		token string
+		proof string
+	}{
+		{"other key", other, nonce, token, proof},
+		{"other nonce", pub, "b3RoZXItbm9uY2Utbm9uY2U", token, proof},
+		{"other token", pub, nonce, "header.payload.other", proof},
+		{"truncated", pub, nonce, token, proof[:20]},
+		{"empty", pub, nonce, token, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyDeviceProof(tt.pub, tt.nonce, tt.token, tt.proof); !errors.Is(err, ErrInvalidProof) {
+				t.Errorf("VerifyDeviceProof() = %v, want ErrInvalidProof", err)
+			}
+		})
+	}
+
+	if err := VerifyDeviceProof(pub[:16], nonce, token, proof); err == nil || errors.Is(err, ErrInvalidProof) {
+		t.Errorf("VerifyDeviceProof() with a short key = %v, want a key size error", err)
+	}
+}
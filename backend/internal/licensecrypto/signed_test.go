@@ -0,0 +1,109 @@
+package licensecrypto
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestEd25519(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+func TestSignedKeyRoundTrip(t *testing.T) {
+	pub, priv := newTestEd25519(t)
+
+	tests := []struct {
+		name string
+		spec KeySpec
+		key  SignedKey
+	}{
+		{"perpetual", DefaultKeySpec, SignedKey{ProductID: 1}},
+		{"expiring", KeySpec{Prefix: "ACME", GroupSize: 5}, SignedKey{ProductID: 42, ExpiresAt: time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)}},
+		{"features", KeySpec{Prefix: "XY", GroupSize: 8}, SignedKey{ProductID: 1<<31 - 1, Features: []string{"pro", "export", ""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := IssueSignedKey(tt.spec, tt.key, priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ValidateKeyFormat(key, tt.spec.Prefix); err != nil {
+				t.Errorf("ValidateKeyFormat(%q) = %v", key, err)
+			}
+
+			for _, input := range []string{key, strings.ToLower(key), strings.ReplaceAll(key, "-", "")} {
+				got, err := VerifySignedKey(input, pub)
+				if err != nil {
+					t.Fatalf("VerifySignedKey(%q): %v", input, err)
+				}
+				if got.ProductID != tt.key.ProductID || !got.ExpiresAt.Equal(tt.key.ExpiresAt) || !slices.Equal(got.Features, tt.key.Features) {
+					t.Errorf("VerifySignedKey(%q) = %+v, want %+v", input, got, tt.key)
+				}
+			}
+		})
+	}
+}
+
+func TestSignedKeyRejected(t *testing.T) {
+	pub, priv := newTestEd25519(t)
+	otherPub, _ := newTestEd25519(t)
+
+	key, err := IssueSignedKey(KeySpec{Prefix: "ACME", GroupSize: 4}, SignedKey{ProductID: 7}, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// flip a symbol of the payload and fix up the check group, as a
+	// forger would
+	c := canonicalize(compact(key))
+	body := []byte(c[:len(c)-checkLen])
+	i := strings.IndexByte(crockford, body[8])
+	body[8] = crockford[(i+1)%len(crockford)]
+	forged := string(body) + checksum(string(body))
+
+	// the same payload and signature under another prefix
+	reprefixed := "ACMF" + c[4:len(c)-checkLen]
+	reprefixed += checksum(reprefixed)
+
+	last := strings.IndexByte(crockford, key[len(key)-1])
+	mistyped := key[:len(key)-1] + string(crockford[(last+1)%len(crockford)])
+
+	tests := []struct {
+		name string
+		key  string
+		pub  ed25519.PublicKey
+		want error
+	}{
+		{"other key", key, otherPub, ErrInvalidSignature},
+		{"forged payload", forged, pub, ErrInvalidSignature},
+		{"other prefix", reprefixed, pub, ErrInvalidSignature},
+		{"mistyped", mistyped, pub, ErrKeyChecksum},
+		{"too short", "ACME", pub, ErrMalformedKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifySignedKey(tt.key, tt.pub); !errors.Is(err, tt.want) {
+				t.Errorf("VerifySignedKey() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignedKeyExpiryBefore1970(t *testing.T) {
+	_, priv := newTestEd25519(t)
+
+	for _, exp := range []time.Time{time.Unix(0, 0), time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)} {
+		if _, err := IssueSignedKey(DefaultKeySpec, SignedKey{ProductID: 1, ExpiresAt: exp}, priv); !errors.Is(err, ErrInvalidExpiry) {
+			t.Errorf("IssueSignedKey(expires %v) = %v, want %v", exp, err, ErrInvalidExpiry)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package licensecrypto
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token formats, named as stored on products.
+const (
+	FormatJWT      = "jwt"
+	FormatPasetoV4 = "paseto_v4"
+	FormatCWT      = "cwt"
+)
+
+// LicenseClaims are the claims of a license token, whatever its format.
+type LicenseClaims struct {
+	ProductID  int32    `json:"product_id"`
+	HWID       string   `json:"hwid,omitempty"`
+	Features   []string `json:"features,omitempty"`
+	LicenseExp *int64   `json:"license_exp,omitempty"`
+	// Cnf binds the token to a device key (RFC 7800).
+	Cnf *Confirmation `json:"cnf,omitempty"`
+	// Nonce is the challenge the client sent with its validation request.
+	Nonce string `json:"nonce,omitempty"`
+	// ServerTime is the signing time in unix milliseconds. Clients keep the
+	// highest value seen to detect a clock set back below it.
+	ServerTime int64 `json:"server_time,omitempty"`
+
+	jwt.RegisteredClaims
+}
+
+type Confirmation struct {
+	// JKT is the RFC 7638 thumbprint of the device's public key.
+	JKT string `json:"jkt"`
+}
+
+// tokenCodec signs and verifies LicenseClaims in one wire format. All
+// formats carry the same claims with the same meaning; products choose the
+// one their clients can verify.
+type tokenCodec interface {
+	encode(claims *LicenseClaims, key SigningKey) (string, error)
+	// decode verifies the signature only; time based claims are left to
+	// the caller.
+	decode(token string, keys KeySet) (*LicenseClaims, error)
+	// supports reports whether the format can be signed with alg.
+	supports(alg string) bool
+}
+
+var tokenCodecs = map[string]tokenCodec{
+	FormatJWT:      jwtCodec{},
+	FormatPasetoV4: pasetoV4Codec{},
+	FormatCWT:      cwtCodec{},
+}
+
+func codecFor(format string) tokenCodec {
+	if c, ok := tokenCodecs[format]; ok {
+		return c
+	}
+	return jwtCodec{}
+}
+
+// FormatSupports reports whether tokens of format can be signed with alg.
+func FormatSupports(format, alg string) bool {
+	c, ok := tokenCodecs[format]
+	return ok && c.supports(alg)
+}
+
+// SignToken encodes and signs claims in format, JWT if empty.
+func SignToken(claims *LicenseClaims, format string, key SigningKey) (string, error) {
+	return codecFor(format).encode(claims, key)
+}
+
+// detectFormat tells the formats apart by shape: PASETO tokens carry their
+// version header, JWTs have three dot separated parts and CWTs are a single
+// base64url blob.
+func detectFormat(token string) string {
+	switch {
+	case strings.HasPrefix(token, pasetoV4Header):
+		return FormatPasetoV4
+	case strings.Count(token, ".") == 2:
+		return FormatJWT
+	default:
+		return FormatCWT
+	}
+}
+
+// DecodeToken verifies the signature of a token in any format without
+// checking its expiry.
+func DecodeToken(token string, keys KeySet) (*LicenseClaims, error) {
+	if IsEncrypted(token) {
+		return nil, errors.New("encrypted tokens must be decrypted before they are verified")
+	}
+
+	claims, err := codecFor(detectFormat(token)).decode(token, keys)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("not a license token")
+	}
+	return claims, nil
+}
+
+// ParseToken verifies a token in any format, including its time based
+// claims.
+func ParseToken(token string, keys KeySet) (*LicenseClaims, error) {
+	claims, err := DecodeToken(token, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := jwt.NewValidator().Validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ErrUnknownKey means a token names a key that is not in the key set, for
+// instance because the server rotated its keys.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// VerificationKey is a public key with the one algorithm it verifies.
+type VerificationKey struct {
+	ID  string
+	Alg string
+	Key crypto.PublicKey
+}
+
+// KeySet is the set of keys tokens are verified against.
+type KeySet []VerificationKey
+
+// NewKeySet builds a key set from pinned public keys, deriving algorithm
+// and key id as NewSigningKey does.
+func NewKeySet(pubs ...crypto.PublicKey) (KeySet, error) {
+	set := make(KeySet, 0, len(pubs))
+	for _, pub := range pubs {
+		jwk, err := PublicJWK(pub)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, VerificationKey{ID: jwk.Thumbprint(), Alg: jwkAlg(jwk), Key: pub})
+	}
+	return set, nil
+}
+
+// find picks the key a token claims to be signed with, falling back to the
+// first EdDSA key for tokens issued before key ids, and makes sure the
+// token's algorithm is the one that key is for.
+func (s KeySet) find(kid, alg string) (crypto.PublicKey, error) {
+	for _, k := range s {
+		if kid == k.ID || kid == "" && k.Alg == AlgEdDSA {
+			if k.Alg != alg {
+				return nil, fmt.Errorf("unexpected signing algorithm %q", alg)
+			}
+			return k.Key, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// jwtSigningMethods maps keyring algorithms to their JWT signing methods.
+var jwtSigningMethods = map[string]jwt.SigningMethod{
+	AlgEdDSA: jwt.SigningMethodEdDSA,
+	AlgES256: jwt.SigningMethodES256,
+	AlgRS256: jwt.SigningMethodRS256,
+}
+
+type jwtCodec struct{}
+
+func (jwtCodec) supports(alg string) bool {
+	_, ok := jwtSigningMethods[alg]
+	return ok
+}
+
+func (jwtCodec) encode(claims *LicenseClaims, key SigningKey) (string, error) {
+	method, ok := jwtSigningMethods[key.Alg]
+	if !ok {
+		return "", fmt.Errorf("unsupported signing algorithm %q", key.Alg)
+	}
+
+	tok := jwt.NewWithClaims(method, claims)
+	tok.Header["kid"] = key.ID
+	return tok.SignedString(key.Signer)
+}
+
+func (jwtCodec) decode(token string, keys KeySet) (*LicenseClaims, error) {
+	claims := &LicenseClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return keys.find(kid, t.Method.Alg())
+	}, jwt.WithValidMethods([]string{AlgEdDSA, AlgES256, AlgRS256}), jwt.WithoutClaimsValidation())
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
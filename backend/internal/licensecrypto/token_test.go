@@ -0,0 +1,181 @@
+package licensecrypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newTestKeyring holds one key of every supported algorithm.
+func newTestKeyring(t *testing.T) *Keyring {
+	t.Helper()
+
+	_, edKey := newTestEd25519(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewKeyring()
+	for _, k := range []crypto.Signer{edKey, ecKey, rsaKey} {
+		if _, err := r.Add(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func testClaims(now time.Time) *LicenseClaims {
+	licenseExp := now.Add(365 * 24 * time.Hour).Unix()
+	return &LicenseClaims{
+		ProductID:  7,
+		HWID:       "device-1",
+		Features:   []string{"pro", "export"},
+		LicenseExp: &licenseExp,
+		Cnf:        &Confirmation{JKT: "0ZcOCORZNYy-DWpqq30jZyJGHTN0d2HglBV3uiguA4I"},
+		Nonce:      "q83vEjRWeJCrze8SNFZ4kA",
+		ServerTime: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "AAECAwQFBgcICQoLDA0ODw",
+			Subject:   "lic_42",
+			Audience:  jwt.ClaimStrings{"test"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now.Add(-30 * time.Second)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+// claimsJSON is the form claims are compared in, so times compare by value.
+func claimsJSON(t *testing.T, claims *LicenseClaims) string {
+	t.Helper()
+	b, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+var tokenFormats = []string{FormatJWT, FormatPasetoV4, FormatCWT}
+
+// TestTokenFormatsRoundTrip signs the same claims with the same keys in
+// every format and expects every format to decode to exactly those claims.
+func TestTokenFormatsRoundTrip(t *testing.T) {
+	keys := newTestKeyring(t)
+	claims := testClaims(time.Now().Truncate(time.Second))
+	want := claimsJSON(t, claims)
+
+	for _, format := range tokenFormats {
+		for _, alg := range []string{AlgEdDSA, AlgES256, AlgRS256} {
+			if !FormatSupports(format, alg) {
+				continue
+			}
+			t.Run(format+"/"+alg, func(t *testing.T) {
+				key, _ := keys.Signer(alg)
+
+				token, err := SignToken(claims, format, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := detectFormat(token); got != format {
+					t.Errorf("detectFormat() = %q, want %q", got, format)
+				}
+
+				got, err := ParseToken(token, keys.Public())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if s := claimsJSON(t, got); s != want {
+					t.Errorf("claims changed in transit\n got %s\nwant %s", s, want)
+				}
+			})
+		}
+	}
+}
+
+func TestTokenFormatSupport(t *testing.T) {
+	tests := []struct {
+		format, alg string
+		want        bool
+	}{
+		{FormatJWT, AlgEdDSA, true},
+		{FormatJWT, AlgES256, true},
+		{FormatJWT, AlgRS256, true},
+		{FormatPasetoV4, AlgEdDSA, true},
+		{FormatPasetoV4, AlgES256, false},
+		{FormatPasetoV4, AlgRS256, false},
+		{FormatCWT, AlgEdDSA, true},
+		{FormatCWT, AlgES256, true},
+		{FormatCWT, AlgRS256, false},
+		{"xml", AlgEdDSA, false},
+	}
+	for _, tt := range tests {
+		if got := FormatSupports(tt.format, tt.alg); got != tt.want {
+			t.Errorf("FormatSupports(%q, %q) = %v, want %v", tt.format, tt.alg, got, tt.want)
+		}
+	}
+}
+
+func TestTokenFormatsReject(t *testing.T) {
+	keys := newTestKeyring(t)
+	other := newTestKeyring(t)
+	now := time.Now().Truncate(time.Second)
+
+	expired := testClaims(now.Add(-2 * time.Hour))
+	notYet := testClaims(now)
+	notYet.NotBefore = jwt.NewNumericDate(now.Add(time.Hour))
+
+	for _, format := range tokenFormats {
+		t.Run(format, func(t *testing.T) {
+			key, _ := keys.Signer(AlgEdDSA)
+			sign := func(claims *LicenseClaims) string {
+				token, err := SignToken(claims, format, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			}
+
+			token := sign(testClaims(now))
+			if _, err := DecodeToken(token, other.Public()); err == nil {
+				t.Error("token verified with another server's keys")
+			}
+
+			// change a character in the middle, where the payload is
+			b := []byte(token)
+			i := len(b) / 2
+			if b[i] == '.' {
+				i++
+			}
+			if b[i] == 'A' {
+				b[i] = 'B'
+			} else {
+				b[i] = 'A'
+			}
+			if _, err := DecodeToken(string(b), keys.Public()); err == nil {
+				t.Error("tampered token verified")
+			}
+
+			if _, err := ParseToken(sign(expired), keys.Public()); err == nil {
+				t.Error("expired token accepted")
+			}
+			if _, err := DecodeToken(sign(expired), keys.Public()); err != nil {
+				t.Errorf("DecodeToken() of an expired token = %v, want the claims", err)
+			}
+			if _, err := ParseToken(sign(notYet), keys.Public()); err == nil {
+				t.Error("token accepted before its nbf")
+			}
+		})
+	}
+}
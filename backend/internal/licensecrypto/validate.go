@@ -0,0 +1,86 @@
+package licensecrypto
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrMalformedKey is returned for input that cannot be a license key.
+	ErrMalformedKey = errors.New("malformed license key")
+	// ErrKeyChecksum is returned when a key has a native prefix but its check
+	// group does not match, which almost always means it was mistyped.
+	ErrKeyChecksum = errors.New("license key checksum mismatch")
+)
+
+// ValidateKeyFormat checks a key without touching the database. prefixes
+// lists the product key prefixes in use in addition to DefaultPrefix. Keys
+// in the legacy LIC format carry no checksum and are accepted as they are;
+// every other key must have a native prefix and a valid check group. Keys
+// imported from other systems cannot be checked, so callers match them
+// against the prefixes registered for them before calling this.
+func ValidateKeyFormat(key string, prefixes ...string) error {
+	n := compact(key)
+	if n == "" {
+		return ErrMalformedKey
+	}
+	if isLegacyKey(n) {
+		return nil
+	}
+
+	prefix, ok := MatchPrefix(key, append(slices.Clip(prefixes), DefaultPrefix))
+	if !ok {
+		return ErrMalformedKey
+	}
+
+	c := canonicalize(n)
+	body := c[len(prefix):]
+	if len(body) <= checkLen || strings.IndexFunc(body, notCrockford) >= 0 {
+		return ErrMalformedKey
+	}
+
+	if checksum(c[:len(c)-checkLen]) != c[len(c)-checkLen:] {
+		return ErrKeyChecksum
+	}
+	return nil
+}
+
+// MatchPrefix reports which of prefixes key starts with. If the key is
+// grouped, only its first group is considered; otherwise the longest
+// matching prefix wins. Comparison uses the canonical form, so a prefix
+// typed with 0 instead of O still matches.
+func MatchPrefix(key string, prefixes []string) (string, bool) {
+	key = strings.TrimSpace(key)
+
+	if i := strings.IndexFunc(key, isSeparator); i >= 0 {
+		head := canonicalize(compact(key[:i]))
+		for _, p := range prefixes {
+			if canonicalize(p) == head {
+				return p, true
+			}
+		}
+		return "", false
+	}
+
+	c := canonicalize(compact(key))
+	sorted := slices.SortedFunc(slices.Values(prefixes), func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+	for _, p := range sorted {
+		if strings.HasPrefix(c, canonicalize(p)) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func notCrockford(r rune) bool {
+	return !strings.ContainsRune(crockford, r)
+}
@@ -0,0 +1,59 @@
+// Package logging provides the request-scoped logger: every line logged
+// while serving a request carries its request ID and route, plus whatever
+// the services learn along the way, such as the license and product.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ctxKey struct{}
+
+// scope is the logging state of one request. Services annotate it as they
+// go, so later lines and the access log see the attributes too.
+type scope struct {
+	base *slog.Logger
+
+	mu    sync.Mutex
+	attrs []any
+}
+
+// WithLogger starts a logging scope for ctx around logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &scope{base: logger})
+}
+
+// FromContext returns the logger of the request ctx belongs to, or the
+// default logger outside of requests.
+func FromContext(ctx context.Context) *slog.Logger {
+	s, ok := ctx.Value(ctxKey{}).(*scope)
+	if !ok {
+		return slog.Default()
+	}
+
+	s.mu.Lock()
+	attrs := append([]any(nil), s.attrs...)
+	s.mu.Unlock()
+
+	if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" {
+		attrs = append(attrs, "route", rc.RoutePattern())
+	}
+	return s.base.With(attrs...)
+}
+
+// Annotate adds key-value pairs to all further log lines of the request,
+// including its access log entry. Outside of requests it does nothing.
+func Annotate(ctx context.Context, args ...any) {
+	s, ok := ctx.Value(ctxKey{}).(*scope)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	s.attrs = append(s.attrs, args...)
+	s.mu.Unlock()
+}
@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// captureLogs sends the default logger to a buffer for the duration of
+// the test and returns a func decoding the lines written so far.
+func captureLogs(t *testing.T) func() []map[string]any {
+	t.Helper()
+
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	return func() []map[string]any {
+		var lines []map[string]any
+		dec := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+		for dec.More() {
+			var line map[string]any
+			if err := dec.Decode(&line); err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	lines := captureLogs(t)
+	ctx := WithLogger(context.Background(), slog.Default().With("requestId", "r1"))
+
+	FromContext(ctx).Info("before")
+	Annotate(ctx, "licenseId", 7)
+	FromContext(ctx).Info("after")
+
+	got := lines()
+	if len(got) != 2 {
+		t.Fatalf("got %d lines, want 2", len(got))
+	}
+	if _, ok := got[0]["licenseId"]; ok {
+		t.Error("annotation leaked into an earlier line")
+	}
+	if got[1]["licenseId"] != float64(7) || got[1]["requestId"] != "r1" {
+		t.Errorf("line = %v, want licenseId 7 and requestId r1", got[1])
+	}
+}
+
+func TestOutsideRequest(t *testing.T) {
+	ctx := context.Background()
+	Annotate(ctx, "licenseId", 7) // must not panic
+
+	if FromContext(ctx) != slog.Default() {
+		t.Error("FromContext outside a request is not the default logger")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		path   string
+		status int
+		level  string
+	}{
+		{"/api/v1/licenses/1", http.StatusOK, "INFO"},
+		{"/api/v1/licenses/1", http.StatusNotFound, "INFO"},
+		{"/api/v1/licenses/1", http.StatusInternalServerError, "ERROR"},
+		{"/healthz", http.StatusOK, "DEBUG"},
+		{"/readyz", http.StatusServiceUnavailable, "ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			lines := captureLogs(t)
+
+			r := chi.NewRouter()
+			r.Use(middleware.RequestID, Middleware)
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				Annotate(r.Context(), "licenseId", 1)
+				w.WriteHeader(tt.status)
+			}
+			r.Get("/api/v1/licenses/{id}", handler)
+			r.Get("/healthz", handler)
+			r.Get("/readyz", handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			id := w.Header().Get(RequestIDHeader)
+			if id == "" {
+				t.Fatalf("no %s header", RequestIDHeader)
+			}
+
+			got := lines()
+			if len(got) != 1 {
+				t.Fatalf("got %d lines, want the access log line", len(got))
+			}
+			line := got[0]
+			if line["level"] != tt.level {
+				t.Errorf("level = %v, want %s", line["level"], tt.level)
+			}
+			if line["requestId"] != id {
+				t.Errorf("requestId = %v, want %s", line["requestId"], id)
+			}
+			if line["status"] != float64(tt.status) {
+				t.Errorf("status = %v, want %d", line["status"], tt.status)
+			}
+			if line["licenseId"] != float64(1) {
+				t.Errorf("licenseId = %v, want the handler's annotation", line["licenseId"])
+			}
+			if tt.path != "/healthz" && tt.path != "/readyz" && line["route"] != "/api/v1/licenses/{id}" {
+				t.Errorf("route = %v, want the route pattern", line["route"])
+			}
+		})
+	}
+}
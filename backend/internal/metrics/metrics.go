@@ -0,0 +1,140 @@
+// Package metrics holds the Prometheus collectors of the server and the
+// handler that exposes them.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+
+	problem "github.com/cheetahbyte/problems"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds all collectors. It is separate from the global default
+// registry so only the metrics below are exposed.
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "clave",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	activations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "clave",
+		Name:      "activations_total",
+		Help:      "License activations by product and outcome.",
+	}, []string{"product", "outcome"})
+
+	validations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "clave",
+		Name:      "validations_total",
+		Help:      "Token validations by outcome.",
+	}, []string{"outcome"})
+
+	signingFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "clave",
+		Name:      "token_signing_failures_total",
+		Help:      "License tokens that could not be signed or encrypted.",
+	}, []string{"format", "alg"})
+
+	argon2Duration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "clave",
+		Name:      "argon2_verify_duration_seconds",
+		Help:      "Time spent verifying license keys against their Argon2 hash.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5},
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestDuration,
+		activations,
+		validations,
+		signingFailures,
+		argon2Duration,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveActivation counts an activation attempt for a product by the
+// error it ended with, nil for success.
+func ObserveActivation(productID int32, err error) {
+	product := "unknown"
+	if productID != 0 {
+		product = strconv.Itoa(int(productID))
+	}
+	activations.WithLabelValues(product, Outcome(err)).Inc()
+}
+
+// ObserveValidation counts a validation by the error it ended with.
+func ObserveValidation(err error) {
+	validations.WithLabelValues(Outcome(err)).Inc()
+}
+
+// SigningFailed counts a token that could not be issued.
+func SigningFailed(format, alg string) {
+	signingFailures.WithLabelValues(format, alg).Inc()
+}
+
+// ObserveArgon2 records how long an Argon2 verification took, in seconds.
+func ObserveArgon2(seconds float64) {
+	argon2Duration.Observe(seconds)
+}
+
+// outcomeByType names the outcomes of problems with a well known type.
+var outcomeByType = map[string]string{
+	"license-not-found": "not_found",
+	"invalid-license":   "invalid",
+	"activation-limit":  "limit_exceeded",
+	"license-revoked":   "revoked",
+}
+
+// Outcome classifies the result of an operation for a metric label, by
+// problem type where it is specific enough and by status otherwise.
+func Outcome(err error) string {
+	if err == nil {
+		return "success"
+	}
+
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		return "error"
+	}
+	if t, ok := p.Get("type"); ok {
+		if s, ok := t.(string); ok {
+			if o, ok := outcomeByType[path.Base(s)]; ok {
+				return o
+			}
+		}
+	}
+
+	status, _ := p.Get("status")
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "invalid"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	default:
+		return "error"
+	}
+}
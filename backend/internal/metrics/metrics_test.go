@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	problem "github.com/cheetahbyte/problems"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOutcome(t *testing.T) {
+	typed := func(status int, typ string) error {
+		return problem.Of(status).Append(problem.Type("https://api.yourapp.dev/problems/" + typ))
+	}
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "success"},
+		{errors.New("boom"), "error"},
+		{typed(404, "license-not-found"), "not_found"},
+		{typed(401, "invalid-license"), "invalid"},
+		{typed(409, "activation-limit"), "limit_exceeded"},
+		{typed(403, "license-revoked"), "revoked"},
+		{typed(409, "device-already-activated"), "conflict"},
+		{problem.Of(400), "bad_request"},
+		{problem.Of(401), "invalid"},
+		{problem.Of(403), "forbidden"},
+		{problem.Of(404), "not_found"},
+		{problem.Of(500), "error"},
+	}
+	for _, tt := range tests {
+		if got := Outcome(tt.err); got != tt.want {
+			t.Errorf("Outcome(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestObserveActivationUnknownProduct(t *testing.T) {
+	before := testutil.ToFloat64(activations.WithLabelValues("unknown", "not_found"))
+	ObserveActivation(0, problem.Of(404))
+	if got := testutil.ToFloat64(activations.WithLabelValues("unknown", "not_found")); got != before+1 {
+		t.Errorf("activations{product=unknown} = %v, want %v", got, before+1)
+	}
+}
+
+// Requests are recorded by route pattern, so ids do not create series.
+func TestMiddlewareRoutePattern(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/licenses/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/licenses/1", "/licenses/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var series []string
+	for _, f := range families {
+		if f.GetName() != "clave_http_request_duration_seconds" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			var s string
+			for _, l := range m.GetLabel() {
+				s += l.GetName() + "=" + l.GetValue() + " "
+			}
+			series = append(series, s)
+		}
+	}
+
+	want := []string{
+		"method=GET route=/licenses/{id} status=204 ",
+		"method=GET route=unmatched status=404 ",
+	}
+	if !slices.Equal(series, want) {
+		t.Errorf("series = %q, want %q", series, want)
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool statistics at scrape time.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquires     *prometheus.Desc
+	acquireTime  *prometheus.Desc
+	emptyWaits   *prometheus.Desc
+	canceled     *prometheus.Desc
+	newConns     *prometheus.Desc
+	lifetimeEnds *prometheus.Desc
+	idleEnds     *prometheus.Desc
+}
+
+// RegisterPool exposes the statistics of the database pool.
+func RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("clave_db_pool_"+name, help, nil, nil)
+	}
+
+	Registry.MustRegister(&poolCollector{
+		pool:         pool,
+		acquired:     desc("acquired_connections", "Connections currently in use."),
+		idle:         desc("idle_connections", "Idle connections."),
+		total:        desc("total_connections", "Open connections."),
+		max:          desc("max_connections", "Maximum size of the pool."),
+		acquires:     desc("acquires_total", "Successful connection acquisitions."),
+		acquireTime:  desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyWaits:   desc("empty_acquires_total", "Acquisitions that had to wait for a connection."),
+		canceled:     desc("canceled_acquires_total", "Acquisitions canceled by their context."),
+		newConns:     desc("new_connections_total", "Connections opened."),
+		lifetimeEnds: desc("max_lifetime_destroys_total", "Connections closed for exceeding their maximum lifetime."),
+		idleEnds:     desc("max_idle_destroys_total", "Connections closed for exceeding their maximum idle time."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquired, float64(s.AcquiredConns()))
+	gauge(c.idle, float64(s.IdleConns()))
+	gauge(c.total, float64(s.TotalConns()))
+	gauge(c.max, float64(s.MaxConns()))
+	counter(c.acquires, float64(s.AcquireCount()))
+	counter(c.acquireTime, s.AcquireDuration().Seconds())
+	counter(c.emptyWaits, float64(s.EmptyAcquireCount()))
+	counter(c.canceled, float64(s.CanceledAcquireCount()))
+	counter(c.newConns, float64(s.NewConnsCount()))
+	counter(c.lifetimeEnds, float64(s.MaxLifetimeDestroyCount()))
+	counter(c.idleEnds, float64(s.MaxIdleDestroyCount()))
+}
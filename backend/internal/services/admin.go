@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// License listings return defaultListLimit rows unless asked for more, up
+// to maxListLimit.
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// ListLicenses returns the newest licenses, of one product if productID is
+// not zero.
+func (svc *LicenseService) ListLicenses(ctx context.Context, productID int32, limit int32) ([]dto.LicenseResponse, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	rows, err := svc.repo.ListLicenses(ctx, db.ListLicensesParams{
+		ProductID: pgtype.Int4{Int32: productID, Valid: productID != 0},
+		RowLimit:  limit,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list licenses", "productId", productID, "err", err)
+		return nil, problem.Of(500).
+			Append(problem.Title("Failed to list licenses")).
+			Append(problem.Instance("/licenses"))
+	}
+
+	out := make([]dto.LicenseResponse, 0, len(rows))
+	for _, l := range rows {
+		out = append(out, licenseResponse(l))
+	}
+	return out, nil
+}
+
+// GetLicense returns a license with its activations.
+func (svc *LicenseService) GetLicense(ctx context.Context, id int32) (dto.LicenseDetailResponse, error) {
+	instance := "/licenses/" + strconv.Itoa(int(id))
+
+	license, err := svc.repo.GetLicenseById(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dto.LicenseDetailResponse{}, problem.Of(404).
+			Append(problem.Type("https://api.yourapp.dev/problems/license-not-found")).
+			Append(problem.Title("License not found")).
+			Append(problem.Instance(instance))
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to load license", "licenseId", id, "err", err)
+		return dto.LicenseDetailResponse{}, problem.Of(500).
+			Append(problem.Title("Failed to load license")).
+			Append(problem.Instance(instance))
+	}
+
+	activations, err := svc.ListActivations(ctx, id)
+	if err != nil {
+		return dto.LicenseDetailResponse{}, err
+	}
+
+	return dto.LicenseDetailResponse{
+		LicenseResponse: licenseResponse(license),
+		Activations:     activations,
+	}, nil
+}
+
+// ListActivations returns the activations of a license.
+func (svc *LicenseService) ListActivations(ctx context.Context, licenseID int32) ([]dto.ActivationResponse, error) {
+	rows, err := svc.repo.GetActivationsForLicense(ctx, pgtype.Int4{Int32: licenseID, Valid: true})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list activations", "licenseId", licenseID, "err", err)
+		return nil, problem.Of(500).
+			Append(problem.Title("Failed to list activations")).
+			Append(problem.Instance("/licenses/" + strconv.Itoa(int(licenseID)) + "/activations"))
+	}
+
+	out := make([]dto.ActivationResponse, 0, len(rows))
+	for _, a := range rows {
+		out = append(out, dto.ActivationResponse{
+			ID:          a.ID,
+			LicenseID:   a.LicenseID.Int32,
+			DeviceID:    a.Hwid,
+			Mode:        string(a.Mode),
+			DeviceBound: a.DevicePublicKey != nil,
+			LastCheckIn: timePtr(a.LastCheckIn),
+			CreatedAt:   a.CreatedAt.Time,
+		})
+	}
+	return out, nil
+}
+
+func licenseResponse(l db.License) dto.LicenseResponse {
+	return dto.LicenseResponse{
+		ID:             l.ID,
+		ProductID:      l.ProductID.Int32,
+		MaxActivations: l.MaxActivations.Int32,
+		Active:         !l.IsActive.Valid || l.IsActive.Bool,
+		KeyType:        string(l.KeyType),
+		Features:       l.Features,
+		BatchID:        l.BatchID.Int32,
+		ExpiresAt:      timePtr(l.ExpiresAt),
+		CreatedAt:      l.CreatedAt.Time,
+	}
+}
+
+func timePtr(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/sync/errgroup"
+)
+
+// MaxBulkLicenses caps how many keys a single batch may generate.
+const MaxBulkLicenses = 10000
+
+type generatedKey struct {
+	key    string
+	digest []byte
+	phc    string
+}
+
+// BulkCreateLicenses generates data.Count licenses for a product in a single
+// transaction and returns their plaintext keys. The keys are never stored and
+// can only be retrieved from this response.
+func (svc *LicenseService) BulkCreateLicenses(ctx context.Context, data dto.BulkLicenseCreationRequest) (dto.BulkLicenseCreationResponse, error) {
+	instance := "/licenses/bulk"
+
+	if data.Count <= 0 || data.Count > MaxBulkLicenses {
+		return dto.BulkLicenseCreationResponse{}, problem.Of(400).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-batch-size")).
+			Append(problem.Title("Invalid batch size")).
+			Append(problem.Detail(fmt.Sprintf("count must be between 1 and %d", MaxBulkLicenses))).
+			Append(problem.Instance(instance))
+	}
+
+	product, err := svc.repo.GetOneById(ctx, data.ProductID)
+	if err != nil {
+		return dto.BulkLicenseCreationResponse{}, problem.Of(404).
+			Append(problem.Type("https://api.yourapp.dev/problems/product-not-found")).
+			Append(problem.Title("Product not found")).
+			Append(problem.Instance(instance))
+	}
+
+	keys, err := generateKeys(ctx, svc.products.KeySpec(product), data.Count, []byte(os.Getenv("LICENSE_HMAC_SECRET")))
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to generate license keys", "count", data.Count, "err", err)
+		return dto.BulkLicenseCreationResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to generate license keys")).
+			Append(problem.Instance(instance))
+	}
+
+	batch, err := svc.insertBatch(ctx, data, keys)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to insert license batch", "productId", data.ProductID, "count", data.Count, "err", err)
+		return dto.BulkLicenseCreationResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to store license batch")).
+			Append(problem.Instance(instance))
+	}
+
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = k.key
+	}
+
+	return dto.BulkLicenseCreationResponse{BatchID: batch.ID, Keys: out}, nil
+}
+
+func (svc *LicenseService) insertBatch(ctx context.Context, data dto.BulkLicenseCreationRequest, keys []generatedKey) (db.LicenseBatch, error) {
+	tx, err := svc.pool.Begin(ctx)
+	if err != nil {
+		return db.LicenseBatch{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := svc.repo.WithTx(tx)
+
+	productId := pgtype.Int4{Int32: data.ProductID, Valid: true}
+	batch, err := q.CreateLicenseBatch(ctx, db.CreateLicenseBatchParams{
+		ProductID: productId,
+		Size:      int32(len(keys)),
+	})
+	if err != nil {
+		return db.LicenseBatch{}, err
+	}
+
+	rows := make([]db.CreateLicensesParams, len(keys))
+	for i, k := range keys {
+		rows[i] = db.CreateLicensesParams{
+			ProductID:      productId,
+			MaxActivations: pgtype.Int4{Int32: data.MaxActivations, Valid: true},
+			LookupDigest:   k.digest,
+			KeyPhc:         k.phc,
+			BatchID:        pgtype.Int4{Int32: batch.ID, Valid: true},
+		}
+	}
+
+	if _, err := q.CreateLicenses(ctx, rows); err != nil {
+		return db.LicenseBatch{}, err
+	}
+
+	return batch, tx.Commit(ctx)
+}
+
+// generateKeys creates n random keys in the given format and hashes them.
+func generateKeys(ctx context.Context, spec licensecrypto.KeySpec, n int, secret []byte) ([]generatedKey, error) {
+	plain := make([]string, n)
+	for i := range plain {
+		key, err := licensecrypto.GenerateKey(spec)
+		if err != nil {
+			return nil, err
+		}
+		plain[i] = key
+	}
+
+	return hashKeys(ctx, plain, secret)
+}
+
+// digestKeys computes only the lookup digests of keys that are not going to
+// be stored.
+func digestKeys(plain []string, secret []byte) []generatedKey {
+	keys := make([]generatedKey, len(plain))
+	for i, key := range plain {
+		keys[i] = generatedKey{key: key, digest: licensecrypto.LookupDigest(secret, key)}
+	}
+	return keys
+}
+
+// hashKeys computes the lookup digest and Argon2 PHC for every key on a worker
+// pool bounded by the number of CPUs, since each Argon2 hash is both CPU and
+// memory heavy.
+func hashKeys(ctx context.Context, plain []string, secret []byte) ([]generatedKey, error) {
+	keys := make([]generatedKey, len(plain))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.NumCPU())
+
+	for i, key := range plain {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			hash, err := licensecrypto.HashKey(key)
+			if err != nil {
+				return err
+			}
+
+			keys[i] = generatedKey{
+				key:    key,
+				digest: licensecrypto.LookupDigest(secret, key),
+				phc:    hash,
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
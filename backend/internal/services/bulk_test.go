@@ -0,0 +1,60 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+func TestGenerateKeys(t *testing.T) {
+	secret := []byte("hmac-secret")
+	spec := licensecrypto.KeySpec{Prefix: "ACME", Bytes: 10, GroupSize: 4}
+
+	keys, err := generateKeys(context.Background(), spec, 3, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("got %d keys, want 3", len(keys))
+	}
+
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if seen[k.key] {
+			t.Errorf("duplicate key %q", k.key)
+		}
+		seen[k.key] = true
+
+		if err := licensecrypto.ValidateKeyFormat(k.key, spec.Prefix); err != nil {
+			t.Errorf("ValidateKeyFormat(%q) = %v", k.key, err)
+		}
+		if !bytes.Equal(k.digest, licensecrypto.LookupDigest(secret, k.key)) {
+			t.Errorf("digest of %q is not its lookup digest", k.key)
+		}
+		if ok, err := licensecrypto.VerifyKey(k.key, k.phc, true); err != nil || !ok {
+			t.Errorf("VerifyKey(%q) = %v, %v", k.key, ok, err)
+		}
+	}
+}
+
+func TestHashKeysStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := hashKeys(ctx, []string{"ACME-0000-0000"}, nil); err == nil {
+		t.Error("hashKeys with a cancelled context succeeded")
+	}
+}
+
+func TestBulkCreateLicensesBatchSize(t *testing.T) {
+	var svc LicenseService
+	for _, count := range []int{-1, 0, MaxBulkLicenses + 1} {
+		_, err := svc.BulkCreateLicenses(context.Background(), dto.BulkLicenseCreationRequest{ProductID: 1, Count: count})
+		if got := problemStatus(err); got != 400 {
+			t.Errorf("count %d: status = %d, want 400", count, got)
+		}
+	}
+}
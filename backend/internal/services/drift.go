@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// rebindNonceTTL is how long the nonce of a rebind proof is remembered. A
+// proof replayed later can only move the activation to the id and key the
+// device itself asked for.
+const rebindNonceTTL = 24 * time.Hour
+
+// ErrDeviceActivated is returned when an activation would move to a device
+// id that already holds another activation of the license.
+var ErrDeviceActivated = errors.New("device already has an activation of this license")
+
+func deviceActivatedProblem(instance string) error {
+	return problem.Of(409).
+		Append(problem.Type("https://api.yourapp.dev/problems/device-already-activated")).
+		Append(problem.Title("Device already activated")).
+		Append(problem.Detail(ErrDeviceActivated.Error())).
+		Append(problem.Instance(instance))
+}
+
+// componentsJSON encodes component hashes for storage.
+func componentsJSON(components map[string]string) []byte {
+	if len(components) == 0 {
+		return []byte("{}")
+	}
+	b, _ := json.Marshal(components)
+	return b
+}
+
+func parseComponents(b []byte) map[string]string {
+	var c map[string]string
+	_ = json.Unmarshal(b, &c)
+	return c
+}
+
+// matchComponents counts the components both fingerprints have with equal
+// hashes and names the ones that differ.
+func matchComponents(stored, presented map[string]string) (matched int, changed []string) {
+	for name, hash := range stored {
+		if p, ok := presented[name]; ok && p == hash {
+			matched++
+		} else {
+			changed = append(changed, name)
+		}
+	}
+	for name := range presented {
+		if _, ok := stored[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return matched, changed
+}
+
+// findDrifted looks for the activation a device had before some of its
+// hardware changed: the one sharing at least the product's threshold of
+// components with the presented fingerprint, best match first. A device
+// that already holds an activation under hwid has not drifted. Products
+// with a threshold of zero only match device ids exactly.
+func findDrifted(product db.Product, activations []db.Activation, hwid string, components map[string]string) (db.Activation, bool) {
+	if product.HwidMatchThreshold <= 0 || len(components) == 0 {
+		return db.Activation{}, false
+	}
+	if slices.ContainsFunc(activations, func(a db.Activation) bool { return a.Hwid == hwid }) {
+		return db.Activation{}, false
+	}
+
+	var best db.Activation
+	bestScore := 0
+	for _, a := range activations {
+		score, _ := matchComponents(parseComponents(a.Components), components)
+		if score >= int(product.HwidMatchThreshold) && score > bestScore {
+			best, bestScore = a, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+// checkRebindProof verifies that an activation request moving a drifted
+// activation comes from the holder of the activation's device key, by its
+// signature over the request's nonce, new device id and new key.
+// Activations without a key need no proof.
+func (svc *LicenseService) checkRebindProof(ctx context.Context, activation db.Activation, data dto.ActivateLicenseRequest, newKey ed25519.PublicKey, instance string) error {
+	if len(activation.DevicePublicKey) == 0 {
+		return nil
+	}
+
+	err := svc.nonces.Consume(ctx, data.Nonce, time.Now().Add(rebindNonceTTL))
+	if err != nil && !errors.Is(err, ErrNonceInvalid) && !errors.Is(err, ErrNonceReused) {
+		logging.FromContext(ctx).Error("failed to record nonce", "activationId", activation.ID, "err", err)
+		return problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Instance(instance))
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("nonce rejected", "activationId", activation.ID, "err", err)
+		return problem.Of(401).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-nonce")).
+			Append(problem.Title("Invalid nonce")).
+			Append(problem.Detail(err.Error())).
+			Append(problem.Instance(instance))
+	}
+
+	err = licensecrypto.VerifyRebindProof(activation.DevicePublicKey, data.Nonce, data.DeviceID, newKey, data.Proof)
+	if err != nil {
+		logging.FromContext(ctx).Warn("rebind proof rejected", "activationId", activation.ID, "hwid", data.DeviceID, "err", err)
+		return problem.Of(401).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-device-proof")).
+			Append(problem.Title("Invalid device proof")).
+			Append(problem.Detail("The proof must be signed by the key the activation is bound to")).
+			Append(problem.Instance(instance))
+	}
+	return nil
+}
+
+// rebindActivation moves a drifted activation to the device's new id and
+// fingerprint and records the drift for review. New device keys replace
+// the stored ones only if given; callers check the old key's proof first.
+// It returns ErrDeviceActivated if hwid holds another activation of the
+// license.
+func (svc *LicenseService) rebindActivation(ctx context.Context, activation db.Activation, hwid string, components map[string]string, devicePub, deviceEncKey []byte) error {
+	stored := parseComponents(activation.Components)
+	matched, changed := matchComponents(stored, components)
+
+	err := svc.repo.UpdateActivationFingerprint(ctx, db.UpdateActivationFingerprintParams{
+		ID:                  activation.ID,
+		Hwid:                hwid,
+		Components:          componentsJSON(components),
+		DevicePublicKey:     devicePub,
+		DeviceEncryptionKey: deviceEncKey,
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrDeviceActivated
+	}
+	if err != nil {
+		return err
+	}
+
+	if changed == nil {
+		changed = []string{}
+	}
+	err = svc.repo.RecordActivationDrift(ctx, db.RecordActivationDriftParams{
+		ActivationID: activation.ID,
+		OldHwid:      activation.Hwid,
+		NewHwid:      hwid,
+		Matched:      int32(matched),
+		Total:        int32(matched + len(changed)),
+		Changed:      changed,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to record activation drift", "activationId", activation.ID, "err", err)
+	}
+
+	logging.FromContext(ctx).Info("activation rebound after hardware drift",
+		"activationId", activation.ID,
+		"oldHwid", activation.Hwid,
+		"newHwid", hwid,
+		"matched", matched,
+		"changed", changed,
+	)
+	return nil
+}
+
+// ActivationDrift lists the fingerprint changes recorded for an activation,
+// oldest first, for fraud review.
+func (svc *LicenseService) ActivationDrift(ctx context.Context, activationID int32) ([]dto.ActivationDrift, error) {
+	rows, err := svc.repo.ListActivationDrift(ctx, activationID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list activation drift", "activationId", activationID, "err", err)
+		return nil, problem.Of(500).
+			Append(problem.Title("Failed to list activation drift")).
+			Append(problem.Instance("/activations/" + strconv.Itoa(int(activationID)) + "/drift"))
+	}
+
+	out := make([]dto.ActivationDrift, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, dto.ActivationDrift{
+			OldHwid:   r.OldHwid,
+			NewHwid:   r.NewHwid,
+			Matched:   r.Matched,
+			Total:     r.Total,
+			Changed:   r.Changed,
+			CreatedAt: r.CreatedAt.Time,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/db"
+)
+
+func activationWith(id int32, hwid string, components map[string]string) db.Activation {
+	return db.Activation{ID: id, Hwid: hwid, Components: componentsJSON(components)}
+}
+
+func TestMatchComponents(t *testing.T) {
+	stored := map[string]string{"machine_id": "a", "mac": "b", "disk_serial": "c"}
+	presented := map[string]string{"machine_id": "a", "mac": "x", "cpu": "d"}
+
+	matched, changed := matchComponents(stored, presented)
+	if matched != 1 {
+		t.Errorf("matched = %d, want 1", matched)
+	}
+	if want := []string{"cpu", "disk_serial", "mac"}; !slices.Equal(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+}
+
+func TestFindDrifted(t *testing.T) {
+	product := db.Product{HwidMatchThreshold: 2}
+	presented := map[string]string{"machine_id": "a", "mac": "b", "disk_serial": "new"}
+
+	weak := activationWith(1, "device-1", map[string]string{"machine_id": "a", "mac": "x", "disk_serial": "y"})
+	good := activationWith(2, "device-2", map[string]string{"machine_id": "a", "mac": "b", "disk_serial": "old"})
+
+	tests := []struct {
+		name        string
+		product     db.Product
+		activations []db.Activation
+		components  map[string]string
+		want        int32
+	}{
+		{"best match", product, []db.Activation{weak, good}, presented, 2},
+		{"below threshold", product, []db.Activation{weak}, presented, 0},
+		{"exact ids only", db.Product{}, []db.Activation{good}, presented, 0},
+		{"no components", product, []db.Activation{good}, nil, 0},
+		// the device already has a seat under its new id; moving another
+		// activation there would collide with it
+		{"already activated", product, []db.Activation{good, activationWith(3, "device-3", nil)}, presented, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findDrifted(tt.product, tt.activations, "device-3", tt.components)
+			if ok != (tt.want != 0) || got.ID != tt.want {
+				t.Errorf("findDrifted() = %d, %v, want %d", got.ID, ok, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	"github.com/cheetahbyte/clave/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+// readinessTimeout bounds each readiness check, so a hanging database
+// fails the probe rather than the orchestrator's own timeout.
+const readinessTimeout = 2 * time.Second
+
+var errNoSigningKey = errors.New("no Ed25519 signing key is configured")
+
+type HealthService struct {
+	pool *pgxpool.Pool
+	keys *licensecrypto.Keyring
+	// schema is nil if the embedded migrations could not be loaded, with
+	// schemaErr saying why.
+	schema    *goose.Provider
+	schemaErr error
+}
+
+func NewHealthService(pool *pgxpool.Pool, keys *licensecrypto.Keyring) *HealthService {
+	schema, err := migrations.NewProvider(stdlib.OpenDBFromPool(pool))
+	return &HealthService{
+		pool:      pool,
+		keys:      keys,
+		schema:    schema,
+		schemaErr: err,
+	}
+}
+
+// Ready checks that the instance can serve licensing requests: the
+// database answers, an Ed25519 signing key is loaded and the schema is at
+// the version the binary was built for. Ready is false if any check failed.
+func (svc *HealthService) Ready(ctx context.Context) (dto.ReadinessResponse, bool) {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	checks := map[string]string{}
+	ready := true
+	record := func(name string, err error) {
+		if err != nil {
+			// the details stay in the log, the endpoint is public
+			logging.FromContext(ctx).Warn("readiness check failed", "check", name, "err", err)
+			checks[name] = "failed"
+			ready = false
+			return
+		}
+		checks[name] = "ok"
+	}
+
+	record("database", svc.pool.Ping(ctx))
+	record("signingKey", svc.signingKey())
+	record("migrations", svc.migrations(ctx))
+
+	status := "ready"
+	if !ready {
+		status = "unavailable"
+	}
+	return dto.ReadinessResponse{Status: status, Checks: checks}, ready
+}
+
+func (svc *HealthService) signingKey() error {
+	if _, ok := svc.keys.Signer(licensecrypto.AlgEdDSA); !ok {
+		return errNoSigningKey
+	}
+	return nil
+}
+
+func (svc *HealthService) migrations(ctx context.Context) error {
+	if svc.schema == nil {
+		return svc.schemaErr
+	}
+	return migrations.Check(ctx, svc.schema)
+}
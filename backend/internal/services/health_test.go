@@ -0,0 +1,58 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+func TestHealthSigningKey(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		keys []crypto.Signer
+		want error
+	}{
+		{"no keys", nil, errNoSigningKey},
+		{"no ed25519 key", []crypto.Signer{ecKey}, errNoSigningKey},
+		{"ed25519 key", []crypto.Signer{ecKey, edKey}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := licensecrypto.NewKeyring()
+			for _, k := range tt.keys {
+				if _, err := keys.Add(k); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			svc := &HealthService{keys: keys}
+			if err := svc.signingKey(); !errors.Is(err, tt.want) {
+				t.Errorf("signingKey() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHealthMigrationsWithoutSchema(t *testing.T) {
+	loadErr := errors.New("no migrations")
+	svc := &HealthService{schemaErr: loadErr}
+
+	if err := svc.migrations(t.Context()); !errors.Is(err, loadErr) {
+		t.Errorf("migrations() = %v, want the load error", err)
+	}
+}
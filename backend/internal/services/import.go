@@ -0,0 +1,388 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MaxImportRows caps how many rows a single import may contain.
+const MaxImportRows = 50000
+
+// importColumns are the CSV header names understood by ParseImportCSV.
+// device_ids holds a semicolon separated list.
+var importColumns = []string{"key", "product", "max_activations", "expires_at", "device_ids"}
+
+type importRow struct {
+	row dto.LicenseImportRow
+	err error
+}
+
+// ImportLicenses creates licenses (and their existing activations) from a
+// CSV or JSONL export of another licensing system. Every row is imported in
+// its own savepoint so a bad row never aborts the others; with dryRun the
+// whole import is rolled back after all rows have been checked.
+func (svc *LicenseService) ImportLicenses(ctx context.Context, r io.Reader, format string, dryRun bool) (dto.LicenseImportResponse, error) {
+	instance := "/licenses/import"
+
+	var (
+		rows []importRow
+		err  error
+	)
+	switch format {
+	case "csv":
+		rows, err = parseImportCSV(r)
+	case "jsonl":
+		rows, err = parseImportJSONL(r)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return dto.LicenseImportResponse{}, problem.Of(400).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-import")).
+			Append(problem.Title("Invalid import file")).
+			Append(problem.Detail(err.Error())).
+			Append(problem.Instance(instance))
+	}
+
+	prefixes, err := svc.products.KeyPrefixes(ctx)
+	var imported map[int32][]string
+	if err == nil {
+		imported, err = svc.products.ImportedKeyPrefixes(ctx)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to load key prefixes", "err", err)
+		return dto.LicenseImportResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to start import")).
+			Append(problem.Instance(instance))
+	}
+
+	plain := make([]string, 0, len(rows))
+	for i := range rows {
+		if rows[i].err == nil {
+			rows[i].err = validateImportRow(&rows[i].row, slices.Collect(maps.Keys(prefixes)), imported)
+		}
+		if rows[i].err == nil {
+			plain = append(plain, rows[i].row.LicenseKey)
+		}
+	}
+
+	// a dry run is rolled back, so it only needs the digests to find
+	// duplicate keys and skips the expensive Argon2 hashes
+	secret := []byte(os.Getenv("LICENSE_HMAC_SECRET"))
+	var hashed []generatedKey
+	if dryRun {
+		hashed = digestKeys(plain, secret)
+	} else {
+		hashed, err = hashKeys(ctx, plain, secret)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to hash imported license keys", "err", err)
+		return dto.LicenseImportResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to hash imported license keys")).
+			Append(problem.Instance(instance))
+	}
+
+	tx, err := svc.pool.Begin(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to begin import transaction", "err", err)
+		return dto.LicenseImportResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to start import")).
+			Append(problem.Instance(instance))
+	}
+	defer tx.Rollback(ctx)
+
+	resp := dto.LicenseImportResponse{DryRun: dryRun, Rows: make([]dto.LicenseImportRowResult, 0, len(rows))}
+	products := map[int32]bool{}
+
+	for _, ir := range rows {
+		result := dto.LicenseImportRowResult{Line: ir.row.Line}
+
+		if ir.err == nil {
+			key := hashed[0]
+			hashed = hashed[1:]
+
+			result.LicenseID, ir.err = svc.importRow(ctx, tx, products, ir.row, key)
+			if ir.err == nil {
+				result.Activations = len(ir.row.DeviceIDs)
+			}
+		}
+
+		if ir.err != nil {
+			result.Error = ir.err.Error()
+			resp.Failed++
+		} else {
+			resp.Imported++
+		}
+		resp.Rows = append(resp.Rows, result)
+	}
+
+	if !dryRun {
+		if err := tx.Commit(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to commit import", "err", err)
+			return dto.LicenseImportResponse{}, problem.Of(500).
+				Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+				Append(problem.Title("Internal error")).
+				Append(problem.Detail("Failed to commit import")).
+				Append(problem.Instance(instance))
+		}
+	}
+
+	logging.FromContext(ctx).Info("license import finished", "dryRun", dryRun, "imported", resp.Imported, "failed", resp.Failed)
+
+	return resp, nil
+}
+
+func (svc *LicenseService) importRow(ctx context.Context, tx pgx.Tx, products map[int32]bool, row dto.LicenseImportRow, key generatedKey) (int32, error) {
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer sp.Rollback(ctx)
+
+	q := svc.repo.WithTx(sp)
+
+	exists, checked := products[row.ProductID]
+	if !checked {
+		_, err := q.GetOneById(ctx, row.ProductID)
+		exists = err == nil
+		products[row.ProductID] = exists
+	}
+	if !exists {
+		return 0, fmt.Errorf("product %d does not exist", row.ProductID)
+	}
+
+	expiresAt := pgtype.Timestamptz{}
+	if row.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: row.ExpiresAt.UTC(), Valid: true}
+	}
+
+	license, err := q.ImportLicense(ctx, db.ImportLicenseParams{
+		ProductID:      pgtype.Int4{Int32: row.ProductID, Valid: true},
+		MaxActivations: pgtype.Int4{Int32: row.MaxActivations, Valid: true},
+		ExpiresAt:      expiresAt,
+		LookupDigest:   key.digest,
+		KeyPhc:         key.phc,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, errors.New("license key already exists")
+		}
+		return 0, fmt.Errorf("failed to insert license: %w", err)
+	}
+
+	licenseId := pgtype.Int4{Int32: license.ID, Valid: true}
+	for _, hwid := range row.DeviceIDs {
+		if _, err := q.ActivateLicense(ctx, db.ActivateLicenseParams{
+			LicenseID:  licenseId,
+			Hwid:       hwid,
+			Mode:       db.ActivationModeImported,
+			Components: componentsJSON(nil),
+		}); err != nil {
+			return 0, fmt.Errorf("failed to create activation for device %q: %w", hwid, err)
+		}
+	}
+
+	return license.ID, sp.Commit(ctx)
+}
+
+// validateImportRow checks and normalizes row. Its key must either be in
+// the native format, given the product key prefixes in use, or carry one
+// of the prefixes imported lists for the row's product.
+func validateImportRow(row *dto.LicenseImportRow, prefixes []string, imported map[int32][]string) error {
+	row.LicenseKey = strings.TrimSpace(row.LicenseKey)
+	if licensecrypto.NormalizeKey(row.LicenseKey) == "" {
+		return errors.New("key is empty")
+	}
+	if row.ProductID <= 0 {
+		return errors.New("product must be a positive id")
+	}
+	if _, ok := licensecrypto.MatchPrefix(row.LicenseKey, imported[row.ProductID]); !ok {
+		if err := licensecrypto.ValidateKeyFormat(row.LicenseKey, prefixes...); err != nil {
+			return fmt.Errorf("key is neither in the native format nor has a prefix registered for imports into product %d: %w", row.ProductID, err)
+		}
+	}
+
+	devices := make([]string, 0, len(row.DeviceIDs))
+	for _, d := range row.DeviceIDs {
+		d = strings.TrimSpace(d)
+		if d != "" && !slices.Contains(devices, d) {
+			devices = append(devices, d)
+		}
+	}
+	row.DeviceIDs = devices
+
+	if row.MaxActivations == 0 {
+		row.MaxActivations = int32(max(1, len(devices)))
+	}
+	if row.MaxActivations < 0 {
+		return errors.New("max_activations must not be negative")
+	}
+	if int(row.MaxActivations) < len(devices) {
+		return fmt.Errorf("%d device ids exceed max_activations of %d", len(devices), row.MaxActivations)
+	}
+
+	return nil
+}
+
+// parseImportCSV reads a CSV file with a header row naming the importColumns.
+// Columns may appear in any order; only key and product are required.
+func parseImportCSV(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(importColumns, name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		cols[name] = i
+	}
+	for _, required := range []string{"key", "product"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	var rows []importRow
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows) >= MaxImportRows {
+			return nil, fmt.Errorf("import exceeds %d rows", MaxImportRows)
+		}
+
+		// FieldPos is only valid for a record that was read successfully
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			rows = append(rows, importRow{row: dto.LicenseImportRow{Line: parseErr.StartLine}, err: parseErr.Err})
+			continue
+		case err != nil:
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row, err := csvImportRow(field)
+		row.Line = line
+		rows = append(rows, importRow{row: row, err: err})
+	}
+
+	return rows, nil
+}
+
+func csvImportRow(field func(string) string) (dto.LicenseImportRow, error) {
+	row := dto.LicenseImportRow{LicenseKey: field("key")}
+
+	product, err := strconv.ParseInt(field("product"), 10, 32)
+	if err != nil {
+		return row, fmt.Errorf("invalid product %q", field("product"))
+	}
+	row.ProductID = int32(product)
+
+	if v := field("max_activations"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return row, fmt.Errorf("invalid max_activations %q", v)
+		}
+		row.MaxActivations = int32(n)
+	}
+
+	if v := field("expires_at"); v != "" {
+		t, err := parseImportTime(v)
+		if err != nil {
+			return row, fmt.Errorf("invalid expires_at %q", v)
+		}
+		row.ExpiresAt = &t
+	}
+
+	if v := field("device_ids"); v != "" {
+		row.DeviceIDs = strings.Split(v, ";")
+	}
+
+	return row, nil
+}
+
+func parseImportTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
+
+// parseImportJSONL reads one dto.LicenseImportRow JSON object per line.
+func parseImportJSONL(r io.Reader) ([]importRow, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var rows []importRow
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		if len(rows) >= MaxImportRows {
+			return nil, fmt.Errorf("import exceeds %d rows", MaxImportRows)
+		}
+
+		var row dto.LicenseImportRow
+		dec := json.NewDecoder(strings.NewReader(text))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&row)
+		if err != nil {
+			err = fmt.Errorf("malformed JSON: %w", err)
+		}
+		row.Line = line
+		rows = append(rows, importRow{row: row, err: err})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	input := strings.Join([]string{
+		"product, KEY, device_ids, expires_at, max_activations",
+		`1, OLD-0001, dev-a;dev-b, 2027-01-31, 3`,
+		`2, "OLD-0002", , 2027-01-31T12:00:00Z,`,
+		`3, OLD-"0003`,
+		`x, OLD-0004`,
+		`4, OLD-0005, , someday`,
+		`5, OLD-0006`,
+	}, "\n")
+
+	rows, err := parseImportCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 6 {
+		t.Fatalf("got %d rows, want 6", len(rows))
+	}
+
+	first := rows[0]
+	if first.err != nil {
+		t.Fatalf("row 1: %v", first.err)
+	}
+	if first.row.Line != 2 || first.row.LicenseKey != "OLD-0001" || first.row.ProductID != 1 || first.row.MaxActivations != 3 {
+		t.Errorf("row 1 = %+v", first.row)
+	}
+	if got := strings.Join(first.row.DeviceIDs, ","); got != "dev-a,dev-b" {
+		t.Errorf("row 1 devices = %q", got)
+	}
+	if want := time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC); !first.row.ExpiresAt.Equal(want) {
+		t.Errorf("row 1 expires at %v, want %v", first.row.ExpiresAt, want)
+	}
+
+	if rows[1].err != nil || rows[1].row.LicenseKey != "OLD-0002" || rows[1].row.DeviceIDs != nil {
+		t.Errorf("row 2 = %+v, %v", rows[1].row, rows[1].err)
+	}
+
+	// a malformed line is reported on its own without stopping the import
+	if !errors.Is(rows[2].err, csv.ErrBareQuote) || rows[2].row.Line != 4 {
+		t.Errorf("row 3 = line %d, %v; want line 4, %v", rows[2].row.Line, rows[2].err, csv.ErrBareQuote)
+	}
+	if rows[3].err == nil || rows[3].row.Line != 5 {
+		t.Errorf("row 4 = line %d, %v; want an invalid product", rows[3].row.Line, rows[3].err)
+	}
+	if rows[4].err == nil || !strings.Contains(rows[4].err.Error(), "expires_at") {
+		t.Errorf("row 5 error = %v, want an invalid expires_at", rows[4].err)
+	}
+	if rows[5].err != nil || rows[5].row.Line != 7 {
+		t.Errorf("row 6 = line %d, %v", rows[5].row.Line, rows[5].err)
+	}
+}
+
+func TestParseImportCSVMalformedFirstRow(t *testing.T) {
+	rows, err := parseImportCSV(strings.NewReader("key,product\n\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || !errors.Is(rows[0].err, csv.ErrQuote) || rows[0].row.Line != 2 {
+		t.Fatalf("rows = %+v, want a quote error on line 2", rows)
+	}
+}
+
+func TestParseImportCSVHeader(t *testing.T) {
+	tests := map[string]string{
+		"unknown column":  "key,product,seats\n",
+		"missing product": "key,max_activations\n",
+		"empty":           "",
+		"malformed":       "key,\"product\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseImportCSV(strings.NewReader(input)); err == nil {
+				t.Error("header was accepted")
+			}
+		})
+	}
+}
+
+func TestParseImportJSONL(t *testing.T) {
+	input := strings.Join([]string{
+		`{"licenseKey":"OLD-0001","productId":1,"deviceIds":["a"]}`,
+		``,
+		`{"licenseKey":"OLD-0002","productId":1,"seats":2}`,
+		`{"licenseKey":`,
+		`{"licenseKey":"OLD-0003","productId":2,"expiresAt":"2027-01-31T00:00:00Z"}`,
+	}, "\n")
+
+	rows, err := parseImportJSONL(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4", len(rows))
+	}
+
+	wantLines := []int{1, 3, 4, 5}
+	wantErr := []bool{false, true, true, false}
+	for i, r := range rows {
+		if r.row.Line != wantLines[i] || (r.err != nil) != wantErr[i] {
+			t.Errorf("row %d = line %d, %v", i, r.row.Line, r.err)
+		}
+	}
+	if rows[3].row.ExpiresAt == nil || rows[3].row.ProductID != 2 {
+		t.Errorf("row 4 = %+v", rows[3].row)
+	}
+}
+
+func TestValidateImportRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		product int32
+		max     int32
+		devices []string
+		wantMax int32
+		wantErr bool
+	}{
+		{name: "seats default to devices", key: " OLD-0001 ", product: 1, devices: []string{"a", " b", "a", ""}, wantMax: 2},
+		{name: "one seat without devices", key: "OLD-0001", product: 1, wantMax: 1},
+		{name: "explicit seats", key: "OLD-0001", product: 1, max: 5, devices: []string{"a"}, wantMax: 5},
+		{name: "too many devices", key: "OLD-0001", product: 1, max: 1, devices: []string{"a", "b"}, wantErr: true},
+		{name: "negative seats", key: "OLD-0001", product: 1, max: -1, wantErr: true},
+		{name: "empty key", key: " -- ", product: 1, wantErr: true},
+		{name: "no product", key: "OLD-0001", wantErr: true},
+		{name: "native key with bad checksum", key: "ACME-0A1B-2C3D-4E5F-6G7H-8J9K-ZZZZ", product: 1, wantErr: true},
+		{name: "unregistered prefix", key: "NEW-0001", product: 1, wantErr: true},
+		{name: "prefix of another product", key: "OLD-0001", product: 2, wantErr: true},
+		{name: "no prefix", key: "12345", product: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := dto.LicenseImportRow{LicenseKey: tt.key, ProductID: tt.product, MaxActivations: tt.max, DeviceIDs: tt.devices}
+			err := validateImportRow(&row, []string{"ACME"}, map[int32][]string{1: {"OLD"}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateImportRow() = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && row.MaxActivations != tt.wantMax {
+				t.Errorf("max activations = %d, want %d", row.MaxActivations, tt.wantMax)
+			}
+			if err == nil && row.LicenseKey != strings.TrimSpace(tt.key) {
+				t.Errorf("key = %q, want it trimmed", row.LicenseKey)
+			}
+		})
+	}
+}
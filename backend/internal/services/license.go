@@ -1,52 +1,94 @@
 package services
 
 import (
+	"cmp"
 	"context"
+	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/x509"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"log/slog"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/alexedwards/argon2id"
 	"github.com/cheetahbyte/clave/internal/db"
 	"github.com/cheetahbyte/clave/internal/handlers/dto"
 	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	"github.com/cheetahbyte/clave/internal/metrics"
+	"github.com/cheetahbyte/clave/internal/tracing"
 	problem "github.com/cheetahbyte/problems"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// tokenPruneInterval is how often an instance deletes the records of
+// expired tokens.
+const tokenPruneInterval = 10 * time.Minute
+
 type LicenseService struct {
-	repo *db.Queries
+	repo       *db.Queries
+	pool       *pgxpool.Pool
+	products   *ProductService
+	nonces     *NonceService
+	privateKey ed25519.PrivateKey
+	keys       *licensecrypto.Keyring
+	// prunedAt is when expired token records were last deleted, unix
+	// seconds.
+	prunedAt atomic.Int64
 }
 
-func NewLicenseService(q *db.Queries) *LicenseService {
+func NewLicenseService(q *db.Queries, pool *pgxpool.Pool, products *ProductService, nonces *NonceService, privateKey ed25519.PrivateKey, keys *licensecrypto.Keyring) *LicenseService {
 	return &LicenseService{
-		repo: q,
+		repo:       q,
+		pool:       pool,
+		products:   products,
+		nonces:     nonces,
+		privateKey: privateKey,
+		keys:       keys,
 	}
 }
 
-func (svc *LicenseService) NewLicense(ctx context.Context, data dto.LicenseCreationRequest) (dto.LicenseCreationResponse, error) {
+func (svc *LicenseService) NewLicense(ctx context.Context, data dto.LicenseCreationRequest) (_ dto.LicenseCreationResponse, err error) {
+	ctx, span := tracing.Start(ctx, "LicenseService.NewLicense", attribute.Int("clave.product_id", int(data.ProductID)))
+	defer func() { tracing.End(span, err) }()
+
 	productId := pgtype.Int4{Int32: int32(data.ProductID), Valid: true}
 	maxActivations := pgtype.Int4{Int32: int32(data.MaxActivations), Valid: true}
 
-	key, _ := licensecrypto.GenerateLicenseKey()
+	product, err := svc.repo.GetOneById(ctx, data.ProductID)
+	if err != nil {
+		return dto.LicenseCreationResponse{}, problem.Of(404).
+			Append(problem.Type("https://api.yourapp.dev/problems/product-not-found")).
+			Append(problem.Title("Product not found")).
+			Append(problem.Instance("/licenses"))
+	}
+
+	key, err := licensecrypto.GenerateKey(svc.products.KeySpec(product))
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to generate license key", "productId", product.ID, "err", err.Error())
+		return dto.LicenseCreationResponse{}, errors.New("failed to generate license key")
+	}
 	digest := licensecrypto.LookupDigest([]byte(os.Getenv("LICENSE_HMAC_SECRET")), key)
 	salt := make([]byte, 16)
-	_, err := rand.Read(salt)
+	_, err = rand.Read(salt)
 	if err != nil {
 		return dto.LicenseCreationResponse{}, errors.New("failed to generate salt")
 	}
 
-	hash, err := argon2id.CreateHash(key, argon2id.DefaultParams)
+	hash, err := licensecrypto.HashKey(key)
 	if err != nil {
-		slog.Error("failed to hash license key", "err", err.Error())
+		logging.FromContext(ctx).Error("failed to hash license key", "err", err.Error())
 		return dto.LicenseCreationResponse{}, errors.New("failed to hash license key")
 	}
 
@@ -58,7 +100,7 @@ func (svc *LicenseService) NewLicense(ctx context.Context, data dto.LicenseCreat
 	})
 
 	if err != nil {
-		slog.Error("failed to create license", "err", err.Error())
+		logging.FromContext(ctx).Error("failed to create license", "err", err.Error())
 		return dto.LicenseCreationResponse{}, errors.New("failed to insert license")
 	}
 
@@ -67,17 +109,61 @@ func (svc *LicenseService) NewLicense(ctx context.Context, data dto.LicenseCreat
 	}, nil
 }
 
-func (svc *LicenseService) issueAndSignToken(license db.License, signingKey ed25519.PrivateKey, audience string, features []string, hwid string, tokenTTL time.Duration) (string, *LicenseClaims, error) {
-	if len(signingKey) != ed25519.PrivateKeySize {
-		return "", nil, errors.New("invalid ed25519 private key size")
+// tokenParams are the per-request parts of a license token.
+type tokenParams struct {
+	Audience string
+	Features []string
+	HWID     string
+	// Cnf binds the token to a device key.
+	Cnf *licensecrypto.Confirmation
+	// Nonce echoes a client supplied challenge.
+	Nonce string
+	TTL   time.Duration
+	// Format is the product's token format, JWT if empty.
+	Format db.TokenFormat
+	// Alg is the product's signing algorithm, EdDSA if empty.
+	Alg db.SigningAlg
+	// EncryptTo, if set, wraps the signed token in a JWE for this key.
+	EncryptTo crypto.PublicKey
+}
+
+// tokenRecipient is the key a token is encrypted to: the device's own key
+// if it registered one, otherwise the product's, if any.
+func tokenRecipient(ctx context.Context, product db.Product, deviceKey []byte) crypto.PublicKey {
+	der := deviceKey
+	if der == nil {
+		der = product.TokenEncryptionKey
+	}
+	if der == nil {
+		return nil
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		logging.FromContext(ctx).Error("stored token encryption key is invalid", "productId", product.ID, "err", err)
+		return nil
+	}
+	return pub
+}
+
+func (svc *LicenseService) issueAndSignToken(ctx context.Context, license db.License, params tokenParams) (string, *licensecrypto.LicenseClaims, error) {
+	alg := string(params.Alg)
+	if alg == "" {
+		alg = licensecrypto.AlgEdDSA
+	}
+	format := cmp.Or(string(params.Format), licensecrypto.FormatJWT)
+	signingKey, ok := svc.keys.Signer(alg)
+	if !ok {
+		metrics.SigningFailed(format, alg)
+		return "", nil, fmt.Errorf("no %s signing key configured", alg)
 	}
 
-	if tokenTTL <= 0 {
+	if params.TTL <= 0 {
 		return "", nil, errors.New("tokenTTL must be > 0")
 	}
 
 	now := time.Now().UTC()
-	expires := now.Add(tokenTTL)
+	expires := now.Add(params.TTL)
 
 	var licenseExp *int64
 	if license.ExpiresAt.Valid {
@@ -88,12 +174,21 @@ func (svc *LicenseService) issueAndSignToken(license db.License, signingKey ed25
 		}
 	}
 
-	claims := &LicenseClaims{
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", nil, errors.New("failed to generate token id")
+	}
+
+	claims := &licensecrypto.LicenseClaims{
 		ProductID:  license.ProductID.Int32,
-		HWID:       hwid,
-		Features:   features,
+		HWID:       params.HWID,
+		Features:   params.Features,
 		LicenseExp: licenseExp,
+		Cnf:        params.Cnf,
+		Nonce:      params.Nonce,
+		ServerTime: now.UnixMilli(),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        base64.RawURLEncoding.EncodeToString(jti),
 			Subject:   fmt.Sprintf("lic_%d", license.ID),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now.Add(-30 * time.Second)),
@@ -101,25 +196,135 @@ func (svc *LicenseService) issueAndSignToken(license db.License, signingKey ed25
 		},
 	}
 
-	if audience != "" {
-		claims.Audience = jwt.ClaimStrings{audience}
+	if params.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{params.Audience}
 	}
 
-	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
-	signed, err := tok.SignedString(signingKey)
+	signed, err := licensecrypto.SignToken(claims, string(params.Format), signingKey)
 	if err != nil {
-		return "", nil, errors.New("failed to sign jwt")
+		metrics.SigningFailed(format, alg)
+		return "", nil, fmt.Errorf("failed to sign %s token: %w", params.Format, err)
+	}
+
+	if params.EncryptTo != nil {
+		signed, err = licensecrypto.EncryptToken(signed, params.EncryptTo)
+		if err != nil {
+			metrics.SigningFailed(format, alg)
+			return "", nil, fmt.Errorf("failed to encrypt token: %w", err)
+		}
+	}
+
+	svc.pruneIssuedTokens(ctx)
+
+	err = svc.repo.RecordIssuedToken(ctx, db.RecordIssuedTokenParams{
+		Jti:       claims.ID,
+		LicenseID: license.ID,
+		ExpiresAt: pgtype.Timestamptz{Time: expires, Valid: true},
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to record token id: %w", err)
 	}
 	return signed, claims, nil
 }
 
+// pruneIssuedTokens deletes the records of expired tokens, at most once per
+// tokenPruneInterval per instance. An expired token fails validation on its
+// own, so its id is no longer needed to revoke or introspect it.
+func (svc *LicenseService) pruneIssuedTokens(ctx context.Context) {
+	now := time.Now().Unix()
+	last := svc.prunedAt.Load()
+	if now-last < int64(tokenPruneInterval/time.Second) || !svc.prunedAt.CompareAndSwap(last, now) {
+		return
+	}
+
+	if err := svc.repo.DeleteExpiredIssuedTokens(ctx); err != nil {
+		logging.FromContext(ctx).Warn("failed to prune issued tokens", "err", err)
+	}
+}
+
 func (svc *LicenseService) ActivateLicense(ctx context.Context, data dto.ActivateLicenseRequest) (dto.ActivateLicenseResponse, error) {
 	instance := "/licenses/activate"
+
+	if err := svc.checkKeyFormat(ctx, data.LicenseKey, instance); err != nil {
+		return dto.ActivateLicenseResponse{}, err
+	}
+	return svc.activate(ctx, data, db.ActivationModeOnline, instance)
+}
+
+// checkKeyFormat rejects mistyped and malformed keys before they cost a
+// database lookup and an Argon2 verification. Keys carrying a prefix
+// registered for imports are let through, as they have no check group.
+func (svc *LicenseService) checkKeyFormat(ctx context.Context, key, instance string) error {
+	prefixes, err := svc.products.KeyPrefixes(ctx)
+	if err != nil {
+		return err
+	}
+	imported, err := svc.products.ImportedKeyPrefixes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := licensecrypto.MatchPrefix(key, slices.Concat(slices.Collect(maps.Values(imported))...)); ok {
+		return nil
+	}
+	err = licensecrypto.ValidateKeyFormat(key, slices.Collect(maps.Keys(prefixes))...)
+	if err == nil {
+		return nil
+	}
+
+	detail := "The provided value is not a license key"
+	if errors.Is(err, licensecrypto.ErrKeyChecksum) {
+		detail = "The license key failed its checksum. Did you mistype it?"
+	}
+	return problem.Of(400).
+		Append(problem.Type("https://api.yourapp.dev/problems/malformed-license-key")).
+		Append(problem.Title("Malformed license key")).
+		Append(problem.Detail(detail)).
+		Append(problem.Instance(instance))
+}
+
+// activate verifies the key, records a new activation for the device and
+// issues its first token.
+func (svc *LicenseService) activate(ctx context.Context, data dto.ActivateLicenseRequest, mode db.ActivationMode, instance string) (_ dto.ActivateLicenseResponse, err error) {
+	ctx, span := tracing.Start(ctx, "LicenseService.activate", attribute.String("clave.activation_mode", string(mode)))
+	productID := data.ProductID
+	defer func() {
+		metrics.ObserveActivation(productID, err)
+		tracing.End(span, err)
+	}()
+
+	var devicePub ed25519.PublicKey
+	if data.DevicePublicKey != "" {
+		b, err := base64.StdEncoding.DecodeString(data.DevicePublicKey)
+		if err != nil || len(b) != ed25519.PublicKeySize {
+			p := problem.Of(400).
+				Append(problem.Type("https://api.yourapp.dev/problems/invalid-device-key")).
+				Append(problem.Title("Invalid device key")).
+				Append(problem.Detail("devicePublicKey must be a base64 encoded Ed25519 public key")).
+				Append(problem.Instance(instance))
+			return dto.ActivateLicenseResponse{}, p
+		}
+		devicePub = b
+	}
+
+	var deviceEncKey []byte
+	if data.DeviceEncryptionKey != "" {
+		if _, err := licensecrypto.ParseEncryptionKey(data.DeviceEncryptionKey); err != nil {
+			p := problem.Of(400).
+				Append(problem.Type("https://api.yourapp.dev/problems/invalid-encryption-key")).
+				Append(problem.Title("Invalid encryption key")).
+				Append(problem.Detail("deviceEncryptionKey must be a base64 PKIX encoded P-256 or RSA public key")).
+				Append(problem.Instance(instance))
+			return dto.ActivateLicenseResponse{}, p
+		}
+		deviceEncKey, _ = base64.StdEncoding.DecodeString(data.DeviceEncryptionKey)
+	}
+
 	lookupDigest := licensecrypto.LookupDigest([]byte(os.Getenv("LICENSE_HMAC_SECRET")), data.LicenseKey)
 
-	license, err := svc.repo.GetLicenseByDigest(ctx, lookupDigest)
+	license, err := svc.lookupLicense(ctx, data, lookupDigest)
 	if err != nil {
-		slog.Warn("license not found", "digest", lookupDigest, "err", err)
+		logging.FromContext(ctx).Warn("license not found", "digest", lookupDigest, "err", err)
 
 		p := problem.Of(404).
 			Append(problem.Type("https://api.yourapp.dev/problems/license-not-found")).
@@ -129,10 +334,21 @@ func (svc *LicenseService) ActivateLicense(ctx context.Context, data dto.Activat
 		return dto.ActivateLicenseResponse{}, p
 	}
 
+	productID = license.ProductID.Int32
+	span.SetAttributes(
+		attribute.Int("clave.license_id", int(license.ID)),
+		attribute.Int("clave.product_id", int(productID)),
+	)
+	logging.Annotate(ctx, "licenseId", license.ID, "productId", productID)
+
 	// validate argon2
-	match, verr := argon2id.ComparePasswordAndHash(data.LicenseKey, license.KeyPhc)
+	_, argonSpan := tracing.Start(ctx, "argon2.verify")
+	start := time.Now()
+	match, verr := licensecrypto.VerifyKey(data.LicenseKey, license.KeyPhc, license.KeyNormalized)
+	metrics.ObserveArgon2(time.Since(start).Seconds())
+	argonSpan.End()
 	if verr != nil || !match {
-		slog.Warn("license verification failed", "licenseId", license.ID, "err", verr)
+		logging.FromContext(ctx).Warn("license verification failed", "licenseId", license.ID, "err", verr)
 
 		p := problem.Of(401).
 			Append(problem.Type("https://api.yourapp.dev/problems/invalid-license")).
@@ -142,22 +358,149 @@ func (svc *LicenseService) ActivateLicense(ctx context.Context, data dto.Activat
 		return dto.ActivateLicenseResponse{}, p
 	}
 
+	if license.IsActive.Valid && !license.IsActive.Bool {
+		logging.FromContext(ctx).Info("activation of revoked license", "licenseId", license.ID)
+
+		p := problem.Of(403).
+			Append(problem.Type("https://api.yourapp.dev/problems/license-revoked")).
+			Append(problem.Title("License revoked")).
+			Append(problem.Detail("This license has been revoked")).
+			Append(problem.Instance(instance))
+		return dto.ActivateLicenseResponse{}, p
+	}
+
+	if !license.KeyNormalized {
+		svc.rehashKey(ctx, license, data.LicenseKey)
+	}
+
 	licenseId := pgtype.Int4{Int32: int32(license.ID), Valid: true}
 
+	product, err := svc.repo.GetOneById(ctx, license.ProductID.Int32)
+	if err != nil {
+		product = db.Product{TokenFormat: db.TokenFormatJwt, SigningAlg: db.SigningAlgEdDSA}
+	}
+
+	if deviceEncKey != nil && product.TokenFormat != db.TokenFormatJwt {
+		p := problem.Of(400).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-encryption-key")).
+			Append(problem.Title("Invalid encryption key")).
+			Append(problem.Detail("Only jwt tokens can be encrypted and this product issues " + string(product.TokenFormat))).
+			Append(problem.Instance(instance))
+		return dto.ActivateLicenseResponse{}, p
+	}
+
+	// A device whose hardware partly changed keeps its seat.
+	var activationId int32
+	var activations []db.Activation
+	if product.HwidMatchThreshold > 0 && len(data.Components) > 0 {
+		activations, err = svc.repo.GetActivationsForLicense(ctx, licenseId)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to list activations", "licenseId", license.ID, "err", err)
+		}
+	}
+	drifted, ok := findDrifted(product, activations, data.DeviceID, data.Components)
+	if ok && len(drifted.DevicePublicKey) > 0 && data.Proof == "" {
+		// Only the holder of the activation's key may move it; anyone else
+		// with the license key takes a new seat.
+		logging.FromContext(ctx).Info("drifted activation not rebound without proof", "activationId", drifted.ID)
+		ok = false
+	}
+	if ok {
+		if err := svc.checkRebindProof(ctx, drifted, data, devicePub, instance); err != nil {
+			return dto.ActivateLicenseResponse{}, err
+		}
+		if err := svc.rebindActivation(ctx, drifted, data.DeviceID, data.Components, devicePub, deviceEncKey); err != nil {
+			if errors.Is(err, ErrDeviceActivated) {
+				return dto.ActivateLicenseResponse{}, deviceActivatedProblem(instance)
+			}
+			logging.FromContext(ctx).Error("failed to rebind activation", "activationId", drifted.ID, "err", err)
+
+			p := problem.Of(500).
+				Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+				Append(problem.Title("Internal error")).
+				Append(problem.Detail("Failed to update activation")).
+				Append(problem.Instance(instance))
+			return dto.ActivateLicenseResponse{}, p
+		}
+		activationId = drifted.ID
+		if devicePub == nil {
+			devicePub = drifted.DevicePublicKey
+		}
+		if deviceEncKey == nil {
+			deviceEncKey = drifted.DeviceEncryptionKey
+		}
+	} else {
+		activationId, err = svc.newActivation(ctx, license, data, mode, devicePub, deviceEncKey, instance)
+		if err != nil {
+			return dto.ActivateLicenseResponse{}, err
+		}
+	}
+
+	ttl := 10 * time.Minute
+	if mode == db.ActivationModeOffline {
+		ttl = offlineTokenTTL
+	}
+
+	var cnf *licensecrypto.Confirmation
+	if devicePub != nil {
+		cnf = &licensecrypto.Confirmation{JKT: licensecrypto.DeviceKeyThumbprint(devicePub)}
+	}
+
+	signed, _, err := svc.issueAndSignToken(ctx, license, tokenParams{
+		Audience:  "test",
+		Features:  []string{"test"},
+		HWID:      data.DeviceID,
+		Cnf:       cnf,
+		TTL:       ttl,
+		Format:    product.TokenFormat,
+		Alg:       product.SigningAlg,
+		EncryptTo: tokenRecipient(ctx, product, deviceEncKey),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to sign jwt", "licenseId", license.ID, "err", err)
+
+		p := problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/token-signing-failed")).
+			Append(problem.Title("Token signing failed")).
+			Append(problem.Detail("Failed to issue activation token")).
+			Append(problem.Instance(instance))
+		return dto.ActivateLicenseResponse{}, p
+	}
+
+	return dto.ActivateLicenseResponse{ActivationId: activationId, Token: signed}, nil
+}
+
+// rehashKey replaces a hash taken over the key as issued by one over the
+// normalized key, now that the key is known. Failing to do so is harmless;
+// it is retried on the next activation.
+func (svc *LicenseService) rehashKey(ctx context.Context, license db.License, key string) {
+	hash, err := licensecrypto.HashKey(key)
+	if err == nil {
+		err = svc.repo.UpdateLicenseKeyHash(ctx, db.UpdateLicenseKeyHashParams{ID: license.ID, KeyPhc: hash})
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to rehash license key", "licenseId", license.ID, "err", err)
+	}
+}
+
+// newActivation takes a seat of the license for a device, if one is left.
+func (svc *LicenseService) newActivation(ctx context.Context, license db.License, data dto.ActivateLicenseRequest, mode db.ActivationMode, devicePub, deviceEncKey []byte, instance string) (int32, error) {
+	licenseId := pgtype.Int4{Int32: license.ID, Valid: true}
+
 	count, err := svc.repo.CountActivations(ctx, licenseId)
 	if err != nil {
-		slog.Error("failed to count activations", "licenseId", license.ID, "err", err)
+		logging.FromContext(ctx).Error("failed to count activations", "licenseId", license.ID, "err", err)
 
 		p := problem.Of(500).
 			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
 			Append(problem.Title("Internal error")).
 			Append(problem.Detail("Failed to process activation request")).
 			Append(problem.Instance(instance))
-		return dto.ActivateLicenseResponse{}, p
+		return 0, p
 	}
 
 	if count >= int64(license.MaxActivations.Int32) {
-		slog.Info(
+		logging.FromContext(ctx).Info(
 			"activation limit exceeded",
 			"licenseId", license.ID,
 			"maxActivations", license.MaxActivations.Int32,
@@ -169,90 +512,55 @@ func (svc *LicenseService) ActivateLicense(ctx context.Context, data dto.Activat
 			Append(problem.Title("Activation limit exceeded")).
 			Append(problem.Detail("No more activations are available for this license")).
 			Append(problem.Instance(instance))
-		return dto.ActivateLicenseResponse{}, p
+		return 0, p
 	}
 
 	activationId, err := svc.repo.ActivateLicense(ctx, db.ActivateLicenseParams{
-		LicenseID: licenseId,
-		Hwid:      data.DeviceID,
+		LicenseID:           licenseId,
+		Hwid:                data.DeviceID,
+		Mode:                mode,
+		DevicePublicKey:     devicePub,
+		DeviceEncryptionKey: deviceEncKey,
+		Components:          componentsJSON(data.Components),
 	})
 	if err != nil {
-		slog.Error("failed to activate license", "licenseId", license.ID, "hwid", data.DeviceID, "err", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return 0, deviceActivatedProblem(instance)
+		}
+		logging.FromContext(ctx).Error("failed to activate license", "licenseId", license.ID, "hwid", data.DeviceID, "err", err)
 
 		p := problem.Of(500).
 			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
 			Append(problem.Title("Internal error")).
 			Append(problem.Detail("Failed to create activation")).
 			Append(problem.Instance(instance))
-		return dto.ActivateLicenseResponse{}, p
-	}
-
-	pkB64 := os.Getenv("LICENSE_JWT_PRIVATE_KEY")
-	pkBytes, err := base64.StdEncoding.DecodeString(pkB64)
-	if err != nil {
-		slog.Error("failed to decode jwt private key", "err", err)
-
-		p := problem.Of(500).
-			Append(problem.Type("https://api.yourapp.dev/problems/server-misconfigured")).
-			Append(problem.Title("Server misconfigured")).
-			Append(problem.Detail("Token signing is not available")).
-			Append(problem.Instance(instance))
-		return dto.ActivateLicenseResponse{}, p
-	}
-
-	priv := ed25519.PrivateKey(pkBytes)
-	if len(priv) != ed25519.PrivateKeySize {
-		slog.Error("invalid ed25519 private key size", "size", len(priv))
-
-		p := problem.Of(500).
-			Append(problem.Type("https://api.yourapp.dev/problems/server-misconfigured")).
-			Append(problem.Title("Server misconfigured")).
-			Append(problem.Detail("Token signing is not available")).
-			Append(problem.Instance(instance))
-		return dto.ActivateLicenseResponse{}, p
-	}
-
-	signed, _, err := svc.issueAndSignToken(license, priv, "test", []string{"test"}, data.DeviceID, 10*time.Minute)
-	if err != nil {
-		slog.Error("failed to sign jwt", "licenseId", license.ID, "err", err)
-
-		p := problem.Of(500).
-			Append(problem.Type("https://api.yourapp.dev/problems/token-signing-failed")).
-			Append(problem.Title("Token signing failed")).
-			Append(problem.Detail("Failed to issue activation token")).
-			Append(problem.Instance(instance))
-		return dto.ActivateLicenseResponse{}, p
+		return 0, p
 	}
 
-	return dto.ActivateLicenseResponse{ActivationId: activationId, Token: signed}, nil
-}
-
-type LicenseClaims struct {
-	ProductID  int32    `json:"product_id"`
-	HWID       string   `json:"hwid,omitempty"`
-	Features   []string `json:"features,omitempty"`
-	LicenseExp *int64   `json:"license_exp,omitempty"`
-
-	jwt.RegisteredClaims
+	return activationId, nil
 }
 
-func parseJWT(tokenString string, pub ed25519.PublicKey) (*LicenseClaims, error) {
-	claims := &LicenseClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
-		if t.Method != jwt.SigningMethodEdDSA {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return pub, nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}))
+// lookupLicense finds the license for an activation request. Keys carrying a
+// product prefix are only looked up within that product, and a request for a
+// different product is rejected without querying licenses at all.
+func (svc *LicenseService) lookupLicense(ctx context.Context, data dto.ActivateLicenseRequest, digest []byte) (db.License, error) {
+	productId, ok, err := svc.products.ProductForKey(ctx, data.LicenseKey)
 	if err != nil {
-		return nil, err
+		return db.License{}, err
+	}
+	if !ok {
+		return svc.repo.GetLicenseByDigest(ctx, digest)
 	}
 
-	if !token.Valid {
-		return nil, errors.New("invalid token")
+	if data.ProductID != 0 && data.ProductID != productId {
+		return db.License{}, fmt.Errorf("key prefix belongs to product %d, not %d", productId, data.ProductID)
 	}
 
-	return claims, nil
+	return svc.repo.GetProductLicenseByDigest(ctx, db.GetProductLicenseByDigestParams{
+		LookupDigest: digest,
+		ProductID:    pgtype.Int4{Int32: productId, Valid: true},
+	})
 }
 
 func licenseIDFromSubject(sub string) (pgtype.Int4, error) {
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/logging"
+)
+
+func TestTokenRecipient(t *testing.T) {
+	der := func() []byte {
+		t.Helper()
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	device, product := der(), der()
+
+	var logs bytes.Buffer
+	ctx := logging.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+
+	tests := []struct {
+		name    string
+		product []byte
+		device  []byte
+		want    []byte
+	}{
+		{"device key first", product, device, device},
+		{"product key", product, nil, product},
+		{"none", nil, nil, nil},
+		{"invalid", []byte("not a key"), nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tokenRecipient(ctx, db.Product{ID: 7, TokenEncryptionKey: tt.product}, tt.device)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("tokenRecipient() = %v, want nil", got)
+				}
+				return
+			}
+			b, err := x509.MarshalPKIXPublicKey(got)
+			if err != nil || !bytes.Equal(b, tt.want) {
+				t.Errorf("tokenRecipient() = %v, want the key of %x", got, tt.want[:8])
+			}
+		})
+	}
+
+	// the invalid key is reported through the request's logger
+	if !strings.Contains(logs.String(), "stored token encryption key is invalid") || !strings.Contains(logs.String(), "productId=7") {
+		t.Errorf("logs = %q, want the invalid key reported", logs.String())
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+)
+
+// ExportLicenseFile produces a signed license file for deployments that
+// check their license offline, optionally locked to a list of devices. The
+// file is signed with the product's signing key and verifies against the
+// JWKS.
+func (svc *LicenseService) ExportLicenseFile(ctx context.Context, licenseID int32, data dto.LicenseFileRequest) ([]byte, error) {
+	instance := fmt.Sprintf("/licenses/%d/file", licenseID)
+
+	if data.Format != "" && data.Format != "json" && data.Format != "pem" {
+		return nil, problem.Of(400).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-license-file-request")).
+			Append(problem.Title("Invalid license file request")).
+			Append(problem.Detail("format must be json or pem")).
+			Append(problem.Instance(instance))
+	}
+
+	license, err := svc.repo.GetLicenseById(ctx, licenseID)
+	if err != nil {
+		return nil, problem.Of(404).
+			Append(problem.Type("https://api.yourapp.dev/problems/license-not-found")).
+			Append(problem.Title("License not found")).
+			Append(problem.Instance(instance))
+	}
+
+	if err := checkExportable(license, time.Now(), instance); err != nil {
+		return nil, err
+	}
+
+	if license.MaxActivations.Valid && len(data.DeviceIDs) > int(license.MaxActivations.Int32) {
+		return nil, problem.Of(400).
+			Append(problem.Type("https://api.yourapp.dev/problems/activation-limit")).
+			Append(problem.Title("Activation limit exceeded")).
+			Append(problem.Detail(fmt.Sprintf("The license allows at most %d devices", license.MaxActivations.Int32))).
+			Append(problem.Instance(instance))
+	}
+
+	product, err := svc.repo.GetOneById(ctx, license.ProductID.Int32)
+	if err != nil {
+		return nil, problem.Of(404).
+			Append(problem.Type("https://api.yourapp.dev/problems/product-not-found")).
+			Append(problem.Title("Product not found")).
+			Append(problem.Instance(instance))
+	}
+
+	file := licensecrypto.LicenseFile{
+		LicenseID:      license.ID,
+		ProductID:      product.ID,
+		ProductName:    product.Name,
+		Features:       license.Features,
+		MaxActivations: license.MaxActivations.Int32,
+		IssuedAt:       time.Now().UTC().Truncate(time.Second),
+		HWIDs:          data.DeviceIDs,
+		Metadata:       data.Metadata,
+	}
+	if license.ExpiresAt.Valid {
+		exp := license.ExpiresAt.Time.UTC()
+		file.ExpiresAt = &exp
+	}
+
+	key, ok := svc.keys.Signer(string(product.SigningAlg))
+	if !ok {
+		logging.FromContext(ctx).Error("no signing key for product", "productId", product.ID, "alg", product.SigningAlg)
+		return nil, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/server-misconfigured")).
+			Append(problem.Title("Server misconfigured")).
+			Append(problem.Detail("License file signing is not available")).
+			Append(problem.Instance(instance))
+	}
+
+	out, err := licensecrypto.SignLicenseFile(file, key, data.Format == "pem")
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to sign license file", "licenseId", license.ID, "err", err)
+		return nil, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/token-signing-failed")).
+			Append(problem.Title("License file signing failed")).
+			Append(problem.Instance(instance))
+	}
+
+	logging.FromContext(ctx).Info("license file exported", "licenseId", license.ID, "devices", len(data.DeviceIDs))
+	return out, nil
+}
+
+// checkExportable refuses licenses a file must not be made for: a file
+// verifies offline for as long as it says, so it would outlive a revocation
+// or resurrect an expired license.
+func checkExportable(license db.License, now time.Time, instance string) error {
+	if license.IsActive.Valid && !license.IsActive.Bool {
+		return problem.Of(403).
+			Append(problem.Type("https://api.yourapp.dev/problems/license-revoked")).
+			Append(problem.Title("License revoked")).
+			Append(problem.Detail("Revoked licenses cannot be exported")).
+			Append(problem.Instance(instance))
+	}
+	if license.ExpiresAt.Valid && !now.Before(license.ExpiresAt.Time) {
+		return problem.Of(403).
+			Append(problem.Type("https://api.yourapp.dev/problems/license-expired")).
+			Append(problem.Title("License expired")).
+			Append(problem.Detail("Expired licenses cannot be exported")).
+			Append(problem.Instance(instance))
+	}
+	return nil
+}
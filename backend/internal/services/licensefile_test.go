@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestCheckExportable(t *testing.T) {
+	now := time.Now()
+	active := pgtype.Bool{Bool: true, Valid: true}
+
+	tests := []struct {
+		name    string
+		license db.License
+		want    int
+	}{
+		{"perpetual", db.License{IsActive: active}, 0},
+		{"unset active flag", db.License{}, 0},
+		{"expires later", db.License{IsActive: active, ExpiresAt: pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true}}, 0},
+		{"expired", db.License{IsActive: active, ExpiresAt: pgtype.Timestamptz{Time: now.Add(-time.Hour), Valid: true}}, 403},
+		{"expires now", db.License{IsActive: active, ExpiresAt: pgtype.Timestamptz{Time: now, Valid: true}}, 403},
+		{"revoked", db.License{IsActive: pgtype.Bool{Bool: false, Valid: true}}, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkExportable(tt.license, now, "/licenses/1/file")
+			if got := problemStatus(err); got != tt.want || (err != nil) != (tt.want != 0) {
+				t.Errorf("checkExportable() = %v, want status %d", err, tt.want)
+			}
+		})
+	}
+}
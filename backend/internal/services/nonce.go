@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// nonce sizes accepted from clients, in bytes before encoding.
+const (
+	minNonceSize = 16
+	maxNonceSize = 64
+)
+
+// nonceTTL is how long a nonce handed out by Issue can be used.
+const nonceTTL = 2 * time.Minute
+
+// issuedNonceSize is the size of an issued nonce: its expiry in unix
+// seconds, 16 random bytes and a 16 byte MAC over both.
+const issuedNonceSize = 8 + 16 + 16
+
+// noncePruneInterval is how often an instance deletes expired nonces.
+const noncePruneInterval = 10 * time.Minute
+
+var (
+	ErrNonceInvalid   = errors.New("nonce must be 16 to 64 random bytes, base64url encoded")
+	ErrNonceReused    = errors.New("nonce has already been used")
+	ErrNonceNotIssued = errors.New("nonce was not issued by this server")
+	ErrNonceExpired   = errors.New("nonce has expired")
+)
+
+// NonceService hands out short-lived challenge nonces and records the
+// nonces sent with validation and heartbeat requests. A request carries
+// either a nonce from Issue or one the client generated; the server echoes
+// it in the signed response, and for device bound tokens it must be an
+// issued one the device signed. Issued nonces are MACed with the server
+// secret, so any instance can check them; the record of used nonces lives
+// in the database, so every instance behind a load balancer rejects a
+// nonce another one has seen.
+type NonceService struct {
+	repo *db.Queries
+	key  []byte
+	// prunedAt is when expired nonces were last deleted, unix seconds.
+	prunedAt atomic.Int64
+}
+
+// NewNonceService derives the key of issued nonces from secret. Without a
+// secret the key is random, so only the issuing instance accepts them.
+func NewNonceService(q *db.Queries, secret []byte) *NonceService {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("clave-nonce"))
+
+	return &NonceService{repo: q, key: mac.Sum(nil)}
+}
+
+// Issue returns a new challenge nonce, valid for nonceTTL.
+func (svc *NonceService) Issue() (dto.NonceResponse, error) {
+	expires := time.Now().Add(nonceTTL).UTC().Truncate(time.Second)
+
+	b := make([]byte, 24, issuedNonceSize)
+	binary.BigEndian.PutUint64(b, uint64(expires.Unix()))
+	if _, err := rand.Read(b[8:]); err != nil {
+		return dto.NonceResponse{}, err
+	}
+	b = append(b, svc.mac(b)...)
+
+	return dto.NonceResponse{
+		Nonce:     base64.RawURLEncoding.EncodeToString(b),
+		ExpiresAt: expires,
+	}, nil
+}
+
+// Verify checks that nonce was handed out by Issue and has not expired. It
+// does not mark the nonce used; Consume does.
+func (svc *NonceService) Verify(nonce string) error {
+	b, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(b) != issuedNonceSize || !hmac.Equal(b[24:], svc.mac(b[:24])) {
+		return ErrNonceNotIssued
+	}
+	if time.Now().After(time.Unix(int64(binary.BigEndian.Uint64(b)), 0)) {
+		return ErrNonceExpired
+	}
+	return nil
+}
+
+func (svc *NonceService) mac(b []byte) []byte {
+	m := hmac.New(sha256.New, svc.key)
+	m.Write(b)
+	return m.Sum(nil)[:16]
+}
+
+// validNonce reports whether nonce is the base64url encoding of
+// minNonceSize to maxNonceSize bytes.
+func validNonce(nonce string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(nonce)
+	return err == nil && len(b) >= minNonceSize && len(b) <= maxNonceSize
+}
+
+// Consume marks nonce used until the token it was sent with expires. After
+// that a replayed request fails on the token, so the nonce can be
+// forgotten.
+func (svc *NonceService) Consume(ctx context.Context, nonce string, until time.Time) error {
+	if !validNonce(nonce) {
+		return ErrNonceInvalid
+	}
+
+	svc.prune(ctx)
+
+	n, err := svc.repo.ConsumeNonce(ctx, db.ConsumeNonceParams{
+		Nonce:     nonce,
+		ExpiresAt: pgtype.Timestamptz{Time: until, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNonceReused
+	}
+	return nil
+}
+
+// prune deletes expired nonces, at most once per noncePruneInterval per
+// instance.
+func (svc *NonceService) prune(ctx context.Context) {
+	now := time.Now().Unix()
+	last := svc.prunedAt.Load()
+	if now-last < int64(noncePruneInterval/time.Second) || !svc.prunedAt.CompareAndSwap(last, now) {
+		return
+	}
+
+	if err := svc.repo.DeleteExpiredNonces(ctx); err != nil {
+		logging.FromContext(ctx).Warn("failed to prune used nonces", "err", err)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidNonce(t *testing.T) {
+	enc := func(n int) string { return base64.RawURLEncoding.EncodeToString(make([]byte, n)) }
+
+	tests := []struct {
+		nonce string
+		want  bool
+	}{
+		{enc(16), true},
+		{enc(32), true},
+		{enc(64), true},
+		{enc(15), false},
+		{enc(65), false},
+		{"", false},
+		{base64.StdEncoding.EncodeToString(make([]byte, 32)), false},
+		{strings.Repeat("+", 43), false},
+	}
+	for _, tt := range tests {
+		if got := validNonce(tt.nonce); got != tt.want {
+			t.Errorf("validNonce(%q) = %v, want %v", tt.nonce, got, tt.want)
+		}
+	}
+}
+
+func TestConsumeRejectsMalformedNonce(t *testing.T) {
+	svc := NewNonceService(nil, nil)
+
+	err := svc.Consume(context.Background(), "short", time.Now().Add(time.Hour))
+	if !errors.Is(err, ErrNonceInvalid) {
+		t.Errorf("Consume() = %v, want %v", err, ErrNonceInvalid)
+	}
+}
+
+func TestIssueNonce(t *testing.T) {
+	svc := NewNonceService(nil, []byte("secret"))
+
+	a, err := svc.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := svc.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Nonce == b.Nonce {
+		t.Error("two issued nonces are equal")
+	}
+	if !validNonce(a.Nonce) {
+		t.Errorf("issued nonce %q cannot be consumed", a.Nonce)
+	}
+	if ttl := time.Until(a.ExpiresAt); ttl <= 0 || ttl > nonceTTL {
+		t.Errorf("nonce expires in %v, want at most %v", ttl, nonceTTL)
+	}
+	if err := svc.Verify(a.Nonce); err != nil {
+		t.Errorf("Verify(issued) = %v", err)
+	}
+	if err := NewNonceService(nil, []byte("secret")).Verify(a.Nonce); err != nil {
+		t.Errorf("Verify(issued) on another instance = %v", err)
+	}
+}
+
+func TestVerifyNonce(t *testing.T) {
+	svc := NewNonceService(nil, []byte("secret"))
+	issued, err := svc.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := base64.RawURLEncoding.DecodeString(issued.Nonce)
+	tampered := append([]byte(nil), raw...)
+	tampered[10] ^= 1
+
+	// a correctly MACed nonce whose expiry has passed
+	expired := make([]byte, 24)
+	binary.BigEndian.PutUint64(expired, uint64(time.Now().Add(-time.Second).Unix()))
+	expired = append(expired, svc.mac(expired)...)
+
+	tests := []struct {
+		name  string
+		nonce string
+		want  error
+	}{
+		{"issued", issued.Nonce, nil},
+		{"client generated", base64.RawURLEncoding.EncodeToString(make([]byte, 32)), ErrNonceNotIssued},
+		{"tampered", base64.RawURLEncoding.EncodeToString(tampered), ErrNonceNotIssued},
+		{"malformed", "not a nonce", ErrNonceNotIssued},
+		{"expired", base64.RawURLEncoding.EncodeToString(expired), ErrNonceExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.Verify(tt.nonce); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if err := NewNonceService(nil, []byte("other")).Verify(issued.Nonce); !errors.Is(err, ErrNonceNotIssued) {
+		t.Errorf("Verify() with another secret = %v, want %v", err, ErrNonceNotIssued)
+	}
+	if err := NewNonceService(nil, nil).Verify(issued.Nonce); !errors.Is(err, ErrNonceNotIssued) {
+		t.Errorf("Verify() without a secret = %v, want %v", err, ErrNonceNotIssued)
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// offlineResponseTTL bounds how long an operator has to carry a response
+// back to the machine that requested it.
+const offlineResponseTTL = 7 * 24 * time.Hour
+
+// offlineTokenTTL is the lifetime of tokens issued to air-gapped machines.
+// They cannot refresh, so the token lasts as long as the license, up to a
+// year, after which the machine is activated offline again.
+const offlineTokenTTL = 365 * 24 * time.Hour
+
+// ActivateOffline activates a license on behalf of a machine that cannot
+// reach the server. The request blob is produced by the client and uploaded
+// by an operator; the signed response binds a long-lived activation token to
+// the client's nonce so it can only be imported on the requesting machine.
+func (svc *LicenseService) ActivateOffline(ctx context.Context, data dto.OfflineActivationRequest) (dto.OfflineActivationResponse, error) {
+	instance := "/licenses/activate/offline"
+
+	blob, err := decodeOfflineBlob(data.Request)
+	if err != nil {
+		return dto.OfflineActivationResponse{}, problem.Of(400).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-activation-request")).
+			Append(problem.Title("Invalid activation request")).
+			Append(problem.Detail(err.Error())).
+			Append(problem.Instance(instance))
+	}
+	if err := svc.checkKeyFormat(ctx, blob.LicenseKey, instance); err != nil {
+		return dto.OfflineActivationResponse{}, err
+	}
+
+	activation, err := svc.activate(ctx, dto.ActivateLicenseRequest{
+		LicenseKey: blob.LicenseKey,
+		DeviceID:   blob.DeviceID,
+		ProductID:  blob.ProductID,
+
+		DevicePublicKey:     blob.DevicePublicKey,
+		DeviceEncryptionKey: blob.DeviceEncryptionKey,
+		Components:          blob.Components,
+
+		Nonce: blob.Nonce,
+		Proof: blob.Proof,
+	}, db.ActivationModeOffline, instance)
+	if err != nil {
+		return dto.OfflineActivationResponse{}, err
+	}
+
+	now := time.Now().UTC()
+	claims := &licensecrypto.OfflineActivationClaims{
+		Nonce:        blob.Nonce,
+		HWID:         blob.DeviceID,
+		ActivationID: activation.ActivationId,
+		Token:        activation.Token,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(offlineResponseTTL)),
+		},
+	}
+
+	signed, err := licensecrypto.SignDocument(claims, licensecrypto.OfflineActivationType, svc.privateKey)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to sign offline activation response", "activationId", activation.ActivationId, "err", err)
+		return dto.OfflineActivationResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/token-signing-failed")).
+			Append(problem.Title("Token signing failed")).
+			Append(problem.Detail("Failed to sign activation response")).
+			Append(problem.Instance(instance))
+	}
+
+	return dto.OfflineActivationResponse{ActivationId: activation.ActivationId, Response: signed}, nil
+}
+
+func decodeOfflineBlob(s string) (dto.OfflineActivationBlob, error) {
+	s = strings.TrimSpace(s)
+
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return dto.OfflineActivationBlob{}, errors.New("request is not base64url encoded")
+	}
+
+	var blob dto.OfflineActivationBlob
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&blob); err != nil {
+		return dto.OfflineActivationBlob{}, errors.New("request is not a valid activation request")
+	}
+
+	switch {
+	case blob.LicenseKey == "":
+		return blob, errors.New("licenseKey is required")
+	case blob.DeviceID == "":
+		return blob, errors.New("deviceId is required")
+	case len(blob.Nonce) < 16 || len(blob.Nonce) > 128:
+		return blob, errors.New("nonce must be between 16 and 128 characters")
+	}
+
+	return blob, nil
+}
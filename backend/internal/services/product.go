@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// keyPrefixTTL bounds how long a prefix added by another instance can go
+// unnoticed.
+const keyPrefixTTL = time.Minute
+
+type ProductService struct {
+	repo *db.Queries
+	keys *licensecrypto.Keyring
+
+	mu       sync.RWMutex
+	prefixes map[string]int32
+	imported map[int32][]string
+	loadedAt time.Time
+}
+
+func NewProductService(q *db.Queries, keys *licensecrypto.Keyring) *ProductService {
+	return &ProductService{
+		repo: q,
+		keys: keys,
+	}
+}
+
+func (svc *ProductService) CreateProduct(ctx context.Context, data dto.ProductCreationRequest) (dto.ProductResponse, error) {
+	instance := "/products"
+
+	var err error
+	spec := licensecrypto.DefaultKeySpec
+	if data.KeyPrefix != "" {
+		spec.Prefix, err = licensecrypto.CanonicalPrefix(data.KeyPrefix)
+	}
+	if data.KeyBytes != 0 {
+		spec.Bytes = int(data.KeyBytes)
+	}
+	if data.KeyGroupSize != 0 {
+		spec.GroupSize = int(data.KeyGroupSize)
+	}
+
+	if err == nil {
+		err = spec.Validate()
+	}
+	format := db.TokenFormatJwt
+	if data.TokenFormat != "" {
+		format = db.TokenFormat(data.TokenFormat)
+	}
+	if err == nil && !format.Valid() {
+		err = errors.New("token format must be one of jwt, paseto_v4 or cwt")
+	}
+	alg := db.SigningAlgEdDSA
+	if data.SigningAlg != "" {
+		alg = db.SigningAlg(data.SigningAlg)
+	}
+	if err == nil && !alg.Valid() {
+		err = errors.New("signing algorithm must be one of EdDSA, ES256 or RS256")
+	}
+	if err == nil && !licensecrypto.FormatSupports(string(format), string(alg)) {
+		err = fmt.Errorf("%s tokens cannot be signed with %s", format, alg)
+	}
+	if _, ok := svc.keys.Signer(string(alg)); err == nil && !ok {
+		err = fmt.Errorf("no %s signing key is configured", alg)
+	}
+	var encryptionKey []byte
+	if err == nil && data.TokenEncryptionKey != "" {
+		if _, err = licensecrypto.ParseEncryptionKey(data.TokenEncryptionKey); err == nil {
+			encryptionKey, _ = base64.StdEncoding.DecodeString(data.TokenEncryptionKey)
+		}
+		if err == nil && format != db.TokenFormatJwt {
+			err = errors.New("only jwt tokens can be encrypted")
+		}
+	}
+	imported := make([]string, 0, len(data.ImportedKeyPrefixes))
+	for _, p := range data.ImportedKeyPrefixes {
+		if err == nil {
+			p, err = licensecrypto.ImportedPrefix(p)
+		}
+		if _, ok := licensecrypto.MatchPrefix(p, []string{spec.Prefix}); err == nil && ok {
+			err = fmt.Errorf("imported key prefix %s reads as the product's own prefix", p)
+		}
+		if err == nil && !slices.Contains(imported, p) {
+			imported = append(imported, p)
+		}
+	}
+	if err == nil && data.HwidMatchThreshold < 0 {
+		err = errors.New("hwid match threshold must not be negative")
+	}
+	if err == nil && strings.TrimSpace(data.Name) == "" {
+		err = errors.New("name is required")
+	}
+	if err != nil {
+		return dto.ProductResponse{}, problem.Of(400).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-product")).
+			Append(problem.Title("Invalid product")).
+			Append(problem.Detail(err.Error())).
+			Append(problem.Instance(instance))
+	}
+
+	product, err := svc.repo.CreateProduct(ctx, db.CreateProductParams{
+		Name:         data.Name,
+		Version:      pgtype.Text{String: data.Version, Valid: data.Version != ""},
+		KeyPrefix:    pgtype.Text{String: spec.Prefix, Valid: data.KeyPrefix != ""},
+		KeyBytes:     int32(spec.Bytes),
+		KeyGroupSize: int32(spec.GroupSize),
+
+		RequireValidationNonce: data.RequireValidationNonce,
+		TokenFormat:            format,
+		SigningAlg:             alg,
+		TokenEncryptionKey:     encryptionKey,
+		HwidMatchThreshold:     data.HwidMatchThreshold,
+		ImportedKeyPrefixes:    imported,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return dto.ProductResponse{}, problem.Of(409).
+				Append(problem.Type("https://api.yourapp.dev/problems/key-prefix-taken")).
+				Append(problem.Title("Key prefix taken")).
+				Append(problem.Detail("Another product already uses this key prefix")).
+				Append(problem.Instance(instance))
+		}
+
+		logging.FromContext(ctx).Error("failed to create product", "err", err)
+		return dto.ProductResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to create product")).
+			Append(problem.Instance(instance))
+	}
+
+	svc.invalidatePrefixes()
+
+	return productResponse(product), nil
+}
+
+func (svc *ProductService) ListProducts(ctx context.Context) ([]dto.ProductResponse, error) {
+	products, err := svc.repo.GetProducts(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list products", "err", err)
+		return nil, errors.New("failed to list products")
+	}
+
+	out := make([]dto.ProductResponse, len(products))
+	for i, p := range products {
+		out[i] = productResponse(p)
+	}
+	return out, nil
+}
+
+// KeySpec returns the key format new licenses of the product are issued in.
+func (svc *ProductService) KeySpec(p db.Product) licensecrypto.KeySpec {
+	spec := licensecrypto.KeySpec{
+		Prefix:    licensecrypto.DefaultPrefix,
+		Bytes:     int(p.KeyBytes),
+		GroupSize: int(p.KeyGroupSize),
+	}
+	if p.KeyPrefix.Valid {
+		spec.Prefix = p.KeyPrefix.String
+	}
+	return spec
+}
+
+// KeyPrefixes maps every product specific key prefix to its product. The
+// result is cached so handlers can check key formats without a query per
+// request.
+func (svc *ProductService) KeyPrefixes(ctx context.Context) (map[string]int32, error) {
+	prefixes, _, err := svc.loadPrefixes(ctx)
+	return prefixes, err
+}
+
+// ImportedKeyPrefixes maps every product to the prefixes registered for
+// keys imported from other systems. It is cached like KeyPrefixes.
+func (svc *ProductService) ImportedKeyPrefixes(ctx context.Context) (map[int32][]string, error) {
+	_, imported, err := svc.loadPrefixes(ctx)
+	return imported, err
+}
+
+func (svc *ProductService) loadPrefixes(ctx context.Context) (map[string]int32, map[int32][]string, error) {
+	svc.mu.RLock()
+	prefixes, imported, loadedAt := svc.prefixes, svc.imported, svc.loadedAt
+	svc.mu.RUnlock()
+
+	if prefixes != nil && time.Since(loadedAt) < keyPrefixTTL {
+		return prefixes, imported, nil
+	}
+
+	rows, err := svc.repo.GetProductKeyPrefixes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prefixes = make(map[string]int32, len(rows))
+	imported = make(map[int32][]string)
+	for _, row := range rows {
+		if row.KeyPrefix.Valid {
+			prefixes[row.KeyPrefix.String] = row.ID
+		}
+		if len(row.ImportedKeyPrefixes) > 0 {
+			imported[row.ID] = row.ImportedKeyPrefixes
+		}
+	}
+
+	svc.mu.Lock()
+	svc.prefixes, svc.imported, svc.loadedAt = prefixes, imported, time.Now()
+	svc.mu.Unlock()
+
+	return prefixes, imported, nil
+}
+
+// ProductForKey returns the product whose prefix the key carries, if any.
+func (svc *ProductService) ProductForKey(ctx context.Context, key string) (int32, bool, error) {
+	prefixes, err := svc.KeyPrefixes(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	names := make([]string, 0, len(prefixes))
+	for p := range prefixes {
+		names = append(names, p)
+	}
+
+	prefix, ok := licensecrypto.MatchPrefix(key, names)
+	if !ok {
+		return 0, false, nil
+	}
+	return prefixes[prefix], true, nil
+}
+
+func (svc *ProductService) invalidatePrefixes() {
+	svc.mu.Lock()
+	svc.prefixes = nil
+	svc.mu.Unlock()
+}
+
+func productResponse(p db.Product) dto.ProductResponse {
+	prefix := licensecrypto.DefaultPrefix
+	if p.KeyPrefix.Valid {
+		prefix = p.KeyPrefix.String
+	}
+
+	return dto.ProductResponse{
+		ID:           p.ID,
+		Name:         p.Name,
+		Version:      p.Version.String,
+		KeyPrefix:    prefix,
+		KeyBytes:     p.KeyBytes,
+		KeyGroupSize: p.KeyGroupSize,
+
+		RequireValidationNonce: p.RequireValidationNonce,
+		TokenFormat:            string(p.TokenFormat),
+		SigningAlg:             string(p.SigningAlg),
+		TokenEncryptionKey:     base64.StdEncoding.EncodeToString(p.TokenEncryptionKey),
+		HwidMatchThreshold:     p.HwidMatchThreshold,
+		ImportedKeyPrefixes:    p.ImportedKeyPrefixes,
+	}
+}
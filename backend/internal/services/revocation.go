@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RevocationService struct {
+	repo       *db.Queries
+	pool       *pgxpool.Pool
+	privateKey ed25519.PrivateKey
+}
+
+func NewRevocationService(q *db.Queries, pool *pgxpool.Pool, privateKey ed25519.PrivateKey) *RevocationService {
+	return &RevocationService{
+		repo:       q,
+		pool:       pool,
+		privateKey: privateKey,
+	}
+}
+
+// RevokeLicense deactivates a license and publishes it on the revocation
+// list.
+func (svc *RevocationService) RevokeLicense(ctx context.Context, id int32) error {
+	instance := "/licenses/" + strconv.Itoa(int(id)) + "/revoke"
+
+	err := svc.revoke(ctx, db.RevocationKindLicense, strconv.Itoa(int(id)), func(q *db.Queries) error {
+		_, err := q.DeactivateLicense(ctx, id)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return problem.Of(404).
+			Append(problem.Type("https://api.yourapp.dev/problems/license-not-found")).
+			Append(problem.Title("License not found")).
+			Append(problem.Instance(instance))
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to revoke license", "licenseId", id, "err", err)
+		return problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to revoke license")).
+			Append(problem.Instance(instance))
+	}
+
+	logging.FromContext(ctx).Info("license revoked", "licenseId", id)
+	return nil
+}
+
+// RevokeActivation removes an activation, freeing its seat, and publishes
+// it on the revocation list.
+func (svc *RevocationService) RevokeActivation(ctx context.Context, id int32) error {
+	instance := "/activations/" + strconv.Itoa(int(id))
+
+	err := svc.revoke(ctx, db.RevocationKindActivation, strconv.Itoa(int(id)), func(q *db.Queries) error {
+		_, err := q.DeleteActivation(ctx, id)
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return problem.Of(404).
+			Append(problem.Type("https://api.yourapp.dev/problems/activation-not-found")).
+			Append(problem.Title("Activation not found")).
+			Append(problem.Instance(instance))
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to revoke activation", "activationId", id, "err", err)
+		return problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to revoke activation")).
+			Append(problem.Instance(instance))
+	}
+
+	logging.FromContext(ctx).Info("activation revoked", "activationId", id)
+	return nil
+}
+
+// RevokeToken publishes a single token id on the revocation list, without
+// affecting the license or activation it was issued for.
+func (svc *RevocationService) RevokeToken(ctx context.Context, jti string) error {
+	err := svc.revoke(ctx, db.RevocationKindToken, jti, func(*db.Queries) error { return nil })
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to revoke token", "jti", jti, "err", err)
+		return problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to revoke token")).
+			Append(problem.Instance("/tokens/revoke"))
+	}
+
+	logging.FromContext(ctx).Info("token revoked", "jti", jti)
+	return nil
+}
+
+// revoke runs change and records the revocation in one transaction.
+// Revoking a subject again keeps its original entry and version.
+func (svc *RevocationService) revoke(ctx context.Context, kind db.RevocationKind, subject string, change func(q *db.Queries) error) error {
+	tx, err := svc.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	q := svc.repo.WithTx(tx)
+	if err := change(q); err != nil {
+		return err
+	}
+
+	// taking the next version locks the counter row until commit, which
+	// publishes revocations strictly in version order
+	version, err := q.NextRevocationVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := q.RecordRevocation(ctx, db.RecordRevocationParams{Kind: kind, Subject: subject, Version: version}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// List returns the revocations recorded after version since, signed with
+// the token signing key. A since ahead of the server (e.g. after a restore)
+// yields the full list. The counter is read before the entries: every
+// version up to it has committed by then, so the list never claims a
+// version whose entry it is missing.
+func (svc *RevocationService) List(ctx context.Context, since int64) (dto.RevocationListResponse, error) {
+	instance := "/revocations"
+
+	latest, err := svc.repo.LatestRevocationVersion(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to read revocation version", "err", err)
+		return dto.RevocationListResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to load revocation list")).
+			Append(problem.Instance(instance))
+	}
+	if since < 0 || since > latest {
+		since = 0
+	}
+
+	rows, err := svc.repo.ListRevocationsSince(ctx, since)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to list revocations", "since", since, "err", err)
+		return dto.RevocationListResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to load revocation list")).
+			Append(problem.Instance(instance))
+	}
+
+	claims := &licensecrypto.RevocationListClaims{
+		Version:     latest,
+		Since:       since,
+		Revocations: make([]licensecrypto.RevocationEntry, len(rows)),
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: jwt.NewNumericDate(time.Now().UTC()),
+		},
+	}
+	for i, row := range rows {
+		claims.Revocations[i] = licensecrypto.RevocationEntry{
+			Version:   row.Version,
+			Kind:      string(row.Kind),
+			ID:        row.Subject,
+			RevokedAt: row.RevokedAt.Time.UTC().Unix(),
+		}
+		claims.Version = max(claims.Version, row.Version)
+	}
+
+	signed, err := licensecrypto.SignDocument(claims, licensecrypto.RevocationListType, svc.privateKey)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to sign revocation list", "err", err)
+		return dto.RevocationListResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/token-signing-failed")).
+			Append(problem.Title("Token signing failed")).
+			Append(problem.Detail("Failed to sign revocation list")).
+			Append(problem.Instance(instance))
+	}
+
+	return dto.RevocationListResponse{Version: claims.Version, List: signed}, nil
+}
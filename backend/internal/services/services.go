@@ -3,42 +3,134 @@ package services
 import (
 	"crypto/ed25519"
 	"encoding/base64"
+	"fmt"
 	"log/slog"
 	"os"
 
 	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type ServiceStack struct {
-	license    *LicenseService
-	validation *ValidationService
+// signingKeyEnv lists the variables signing keys are read from. The
+// Ed25519 key also signs revocation lists, time assertions and offline
+// activations; ES256 and RS256 keys are only for products that select them.
+var signingKeyEnv = []string{
+	"LICENSE_JWT_PRIVATE_KEY",
+	"LICENSE_ES256_PRIVATE_KEY",
+	"LICENSE_RS256_PRIVATE_KEY",
 }
 
-func InitServices(q *db.Queries) ServiceStack {
-	license := NewLicenseService(q)
-	publicKey := os.Getenv("LICENSE_JWT_PUBLIC_KEY")
-	pbBytes, err := base64.StdEncoding.DecodeString(publicKey)
-	if err != nil {
-		slog.Error("failed to decode jwt public key", "err", err)
+// publicKeyEnv is the Ed25519 public key clients pin. The server derives
+// its public keys from the signing keys, so the variable is optional, but
+// if set it must match LICENSE_JWT_PRIVATE_KEY.
+const publicKeyEnv = "LICENSE_JWT_PUBLIC_KEY"
+
+// CheckKeyConfig fails if the public key in LICENSE_JWT_PUBLIC_KEY is not
+// the public half of the Ed25519 signing key, since clients pinning it
+// would reject every token. The server calls it before it starts.
+func CheckKeyConfig() error {
+	return checkPublicKey(os.Getenv(publicKeyEnv), os.Getenv(signingKeyEnv[0]))
+}
+
+func checkPublicKey(pubB64, privB64 string) error {
+	if pubB64 == "" {
+		return nil
 	}
-	pub := ed25519.PublicKey(pbBytes)
-	if len(pub) != ed25519.PublicKeySize {
-		slog.Error("invalid ed25519 public key size", "size", len(pub))
+
+	raw, err := base64.StdEncoding.DecodeString(pubB64)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return fmt.Errorf("%s must be a base64 encoded %d byte Ed25519 public key", publicKeyEnv, ed25519.PublicKeySize)
 	}
-	privateKey := os.Getenv("LICENSE_JWT_PRIVATE_KEY")
-	pkBytes, err := base64.StdEncoding.DecodeString(privateKey)
+	if privB64 == "" {
+		return fmt.Errorf("%s is set but %s is not", publicKeyEnv, signingKeyEnv[0])
+	}
+
+	signer, err := licensecrypto.ParsePrivateKey(privB64)
 	if err != nil {
-		slog.Error("failed to decode jwt private key", "err", err)
+		return fmt.Errorf("%s: %w", signingKeyEnv[0], err)
+	}
+	priv, ok := signer.(ed25519.PrivateKey)
+	if !ok {
+		return fmt.Errorf("%s is not an Ed25519 key", signingKeyEnv[0])
+	}
+	if !priv.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(raw)) {
+		return fmt.Errorf("%s does not match %s; clients pinning it would reject every token", publicKeyEnv, signingKeyEnv[0])
+	}
+	return nil
+}
+
+type ServiceStack struct {
+	product    *ProductService
+	license    *LicenseService
+	validation *ValidationService
+	revocation *RevocationService
+	token      *TokenService
+	nonce      *NonceService
+	time       *TimeService
+	health     *HealthService
+	keys       *licensecrypto.Keyring
+}
+
+func InitServices(q *db.Queries, pool *pgxpool.Pool) ServiceStack {
+	keys := licensecrypto.NewKeyring()
+	var priv ed25519.PrivateKey
+	for _, env := range signingKeyEnv {
+		b64 := os.Getenv(env)
+		if b64 == "" {
+			continue
+		}
+
+		signer, err := licensecrypto.ParsePrivateKey(b64)
+		if err == nil {
+			_, err = keys.Add(signer)
+		}
+		if err != nil {
+			slog.Error("failed to load signing key", "env", env, "err", err)
+			continue
+		}
+		if k, ok := signer.(ed25519.PrivateKey); ok && priv == nil {
+			priv = k
+		}
 	}
-	priv := ed25519.PrivateKey(pkBytes)
-	if len(priv) != ed25519.PrivateKeySize {
-		slog.Error("invalid ed25519 private key size", "size", len(priv))
+	if priv == nil {
+		slog.Error("no ed25519 signing key configured", "env", signingKeyEnv[0])
 	}
 
-	validation := NewValidationService(q, license, pub, priv)
-	return ServiceStack{license: license, validation: validation}
+	product := NewProductService(q, keys)
+	nonces := NewNonceService(q, []byte(os.Getenv("LICENSE_HMAC_SECRET")))
+	license := NewLicenseService(q, pool, product, nonces, priv, keys)
+	validation := NewValidationService(q, license, nonces, keys)
+	revocation := NewRevocationService(q, pool, priv)
+	token := NewTokenService(q, revocation, validation, keys)
+
+	return ServiceStack{
+		product:    product,
+		license:    license,
+		validation: validation,
+		revocation: revocation,
+		token:      token,
+		nonce:      nonces,
+		time:       NewTimeService(priv),
+		health:     NewHealthService(pool, keys),
+		keys:       keys,
+	}
 }
 
+func (s ServiceStack) Product() *ProductService { return s.product }
+
 func (s ServiceStack) License() *LicenseService { return s.license }
 
 func (s ServiceStack) Validation() *ValidationService { return s.validation }
+
+func (s ServiceStack) Revocation() *RevocationService { return s.revocation }
+
+func (s ServiceStack) Token() *TokenService { return s.token }
+
+func (s ServiceStack) Nonce() *NonceService { return s.nonce }
+
+func (s ServiceStack) Time() *TimeService { return s.time }
+
+func (s ServiceStack) Health() *HealthService { return s.health }
+
+func (s ServiceStack) Keys() *licensecrypto.Keyring { return s.keys }
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+)
+
+func TestCheckPublicKey(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		pub, priv string
+		ok        bool
+	}{
+		{"unset", "", b64(priv), true},
+		{"unset without signing key", "", "", true},
+		{"matching", b64(pub), b64(priv), true},
+		{"other key", b64(otherPub), b64(priv), false},
+		{"not base64", "not base64!", b64(priv), false},
+		{"wrong size", b64(pub[:16]), b64(priv), false},
+		{"no signing key", b64(pub), "", false},
+		{"not ed25519", b64(pub), b64(ecDER), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPublicKey(tt.pub, tt.priv)
+			if (err == nil) != tt.ok {
+				t.Errorf("checkPublicKey() = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
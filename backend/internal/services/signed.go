@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NewSignedLicense issues a key that encodes the product, expiry and
+// features and is signed with the token signing key, so client SDKs can
+// verify it without reaching the server. The license is still registered
+// like any other, so it can be activated, revoked and audited online.
+func (svc *LicenseService) NewSignedLicense(ctx context.Context, data dto.SignedLicenseCreationRequest) (dto.LicenseCreationResponse, error) {
+	instance := "/licenses/signed"
+
+	product, err := svc.repo.GetOneById(ctx, data.ProductID)
+	if err != nil {
+		return dto.LicenseCreationResponse{}, problem.Of(404).
+			Append(problem.Type("https://api.yourapp.dev/problems/product-not-found")).
+			Append(problem.Title("Product not found")).
+			Append(problem.Instance(instance))
+	}
+
+	content := licensecrypto.SignedKey{
+		ProductID: product.ID,
+		Features:  data.Features,
+	}
+	expiresAt := pgtype.Timestamptz{}
+	if data.ExpiresAt != nil {
+		// the key only stores whole seconds
+		content.ExpiresAt = data.ExpiresAt.UTC().Truncate(time.Second)
+		expiresAt = pgtype.Timestamptz{Time: content.ExpiresAt, Valid: true}
+	}
+
+	key, err := licensecrypto.IssueSignedKey(svc.products.KeySpec(product), content, svc.privateKey)
+	if errors.Is(err, licensecrypto.ErrInvalidExpiry) {
+		return dto.LicenseCreationResponse{}, problem.Of(400).
+			Append(problem.Type("https://api.yourapp.dev/problems/invalid-expiry")).
+			Append(problem.Title("Invalid expiry")).
+			Append(problem.Detail(err.Error())).
+			Append(problem.Instance(instance))
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to issue signed license key", "productId", product.ID, "err", err)
+		return dto.LicenseCreationResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/server-misconfigured")).
+			Append(problem.Title("Server misconfigured")).
+			Append(problem.Detail("Key signing is not available")).
+			Append(problem.Instance(instance))
+	}
+
+	hash, err := licensecrypto.HashKey(key)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to hash license key", "err", err)
+		return dto.LicenseCreationResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to hash license key")).
+			Append(problem.Instance(instance))
+	}
+
+	features := data.Features
+	if features == nil {
+		features = []string{}
+	}
+
+	_, err = svc.repo.CreateSignedLicense(ctx, db.CreateSignedLicenseParams{
+		ProductID:      pgtype.Int4{Int32: product.ID, Valid: true},
+		MaxActivations: pgtype.Int4{Int32: data.MaxActivations, Valid: true},
+		ExpiresAt:      expiresAt,
+		Features:       features,
+		LookupDigest:   licensecrypto.LookupDigest([]byte(os.Getenv("LICENSE_HMAC_SECRET")), key),
+		KeyPhc:         hash,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to create signed license", "err", err)
+		return dto.LicenseCreationResponse{}, problem.Of(500).
+			Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+			Append(problem.Title("Internal error")).
+			Append(problem.Detail("Failed to insert license")).
+			Append(problem.Instance(instance))
+	}
+
+	return dto.LicenseCreationResponse{LicenseKey: key}, nil
+}
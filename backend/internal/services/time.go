@@ -0,0 +1,60 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TimeAssertionType is the typ header of signed time assertions.
+const TimeAssertionType = "clave-time+jwt"
+
+// TimeAssertionClaims states the server time at signing. Clients use it to
+// move their clock high-water mark forward and to notice a local clock that
+// is behind it.
+type TimeAssertionClaims struct {
+	// ServerTime is the server time in unix milliseconds.
+	ServerTime int64 `json:"server_time"`
+	// Nonce echoes the client's nonce, so an assertion cannot be replayed
+	// into a later request.
+	Nonce string `json:"nonce,omitempty"`
+
+	jwt.RegisteredClaims
+}
+
+type TimeService struct {
+	privateKey ed25519.PrivateKey
+}
+
+func NewTimeService(privateKey ed25519.PrivateKey) *TimeService {
+	return &TimeService{privateKey: privateKey}
+}
+
+// Assert signs the current server time.
+func (svc *TimeService) Assert(nonce string) (dto.TimeResponse, error) {
+	if len(nonce) > 128 {
+		return dto.TimeResponse{}, errors.New("nonce must be at most 128 characters")
+	}
+
+	now := time.Now().UTC()
+	claims := &TimeAssertionClaims{
+		ServerTime: now.UnixMilli(),
+		Nonce:      nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	}
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	tok.Header["typ"] = TimeAssertionType
+	signed, err := tok.SignedString(svc.privateKey)
+	if err != nil {
+		return dto.TimeResponse{}, errors.New("failed to sign time assertion")
+	}
+
+	return dto.TimeResponse{Time: now, Assertion: signed}, nil
+}
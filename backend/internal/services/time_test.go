@@ -0,0 +1,64 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTimeAssert(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := NewTimeService(priv)
+
+	before := time.Now()
+	resp, err := svc.Assert("client-nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims TimeAssertionClaims
+	tok, err := jwt.ParseWithClaims(resp.Assertion, &claims, func(*jwt.Token) (any, error) { return pub, nil },
+		jwt.WithValidMethods([]string{"EdDSA"}))
+	if err != nil {
+		t.Fatalf("assertion does not verify: %v", err)
+	}
+	if typ := tok.Header["typ"]; typ != TimeAssertionType {
+		t.Errorf("typ = %v, want %s", typ, TimeAssertionType)
+	}
+	if claims.Nonce != "client-nonce" {
+		t.Errorf("nonce = %q, want the client's", claims.Nonce)
+	}
+	if claims.ServerTime != resp.Time.UnixMilli() {
+		t.Errorf("server_time = %d, want the response time %d", claims.ServerTime, resp.Time.UnixMilli())
+	}
+	if st := time.UnixMilli(claims.ServerTime); st.Before(before.Truncate(time.Millisecond)) || st.After(time.Now()) {
+		t.Errorf("server_time = %v, want the time of the call", st)
+	}
+	if ttl := claims.ExpiresAt.Sub(claims.IssuedAt.Time); ttl != 5*time.Minute {
+		t.Errorf("assertion lifetime = %v, want 5m", ttl)
+	}
+
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(resp.Assertion, func(*jwt.Token) (any, error) { return other, nil }); err == nil {
+		t.Error("assertion verifies with another key")
+	}
+}
+
+func TestTimeAssertRejectsLongNonce(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTimeService(priv).Assert(strings.Repeat("n", 129)); err == nil {
+		t.Error("Assert accepted a 129 character nonce")
+	}
+}
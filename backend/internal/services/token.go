@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	problem "github.com/cheetahbyte/problems"
+)
+
+// TokenIntrospection is an RFC 7662 introspection response. The claims are
+// only present for active tokens.
+type TokenIntrospection struct {
+	Active bool `json:"active"`
+
+	*licensecrypto.LicenseClaims
+}
+
+type TokenService struct {
+	repo        *db.Queries
+	revocations *RevocationService
+	validation  *ValidationService
+	keys        *licensecrypto.Keyring
+}
+
+func NewTokenService(q *db.Queries, revocations *RevocationService, validation *ValidationService, keys *licensecrypto.Keyring) *TokenService {
+	return &TokenService{
+		repo:        q,
+		revocations: revocations,
+		validation:  validation,
+		keys:        keys,
+	}
+}
+
+// Revoke kills a single token by its jti. A token can be given instead of a
+// jti; its signature is checked, but not its expiry. A bare jti has to name
+// a token the server issued.
+func (svc *TokenService) Revoke(ctx context.Context, data dto.TokenRevocationRequest) error {
+	instance := "/tokens/revoke"
+
+	jti := data.JTI
+	if data.Token != "" {
+		claims, err := licensecrypto.DecodeToken(data.Token, svc.keys.Public())
+		if err != nil || claims.ID == "" {
+			return problem.Of(400).
+				Append(problem.Type("https://api.yourapp.dev/problems/invalid-token")).
+				Append(problem.Title("Invalid token")).
+				Append(problem.Detail("The token is not a license token with a token id")).
+				Append(problem.Instance(instance))
+		}
+		if jti != "" && jti != claims.ID {
+			return problem.Of(400).
+				Append(problem.Title("Token id mismatch")).
+				Append(problem.Detail("jti does not match the given token")).
+				Append(problem.Instance(instance))
+		}
+		jti = claims.ID
+	}
+
+	if jti == "" {
+		return problem.Of(400).
+			Append(problem.Title("Missing token")).
+			Append(problem.Detail("Either token or jti is required")).
+			Append(problem.Instance(instance))
+	}
+
+	// a bare jti has not been vouched for by a signature
+	if data.Token == "" {
+		if !validTokenID(jti) {
+			return problem.Of(400).
+				Append(problem.Type("https://api.yourapp.dev/problems/invalid-token-id")).
+				Append(problem.Title("Invalid token id")).
+				Append(problem.Detail("jti must be the 22 character token id of a license token")).
+				Append(problem.Instance(instance))
+		}
+
+		issued, err := svc.repo.IssuedTokenExists(ctx, jti)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to look up token id", "jti", jti, "err", err)
+			return problem.Of(500).
+				Append(problem.Type("https://api.yourapp.dev/problems/internal")).
+				Append(problem.Title("Internal error")).
+				Append(problem.Detail("Failed to revoke token")).
+				Append(problem.Instance(instance))
+		}
+		if !issued {
+			return problem.Of(404).
+				Append(problem.Type("https://api.yourapp.dev/problems/token-not-found")).
+				Append(problem.Title("Token not found")).
+				Append(problem.Detail("No token with this id has been issued")).
+				Append(problem.Instance(instance))
+		}
+	}
+
+	return svc.revocations.RevokeToken(ctx, jti)
+}
+
+// validTokenID reports whether jti has the shape of the ids
+// issueAndSignToken generates: 16 random bytes, base64url without padding.
+func validTokenID(jti string) bool {
+	raw, err := base64.RawURLEncoding.Strict().DecodeString(jti)
+	return err == nil && len(raw) == 16
+}
+
+// Introspect reports whether a token is currently accepted, by the same
+// checks /validate applies before it looks at the presenting device.
+func (svc *TokenService) Introspect(ctx context.Context, token string) (TokenIntrospection, error) {
+	claims, _, _, err := svc.validation.verifyToken(ctx, token, "/tokens/introspect")
+	if serverError(err) {
+		return TokenIntrospection{}, err
+	}
+	if err != nil {
+		return TokenIntrospection{}, nil
+	}
+
+	return TokenIntrospection{Active: true, LicenseClaims: claims}, nil
+}
+
+// serverError reports whether err is a failure of the server rather than a
+// rejection of the request: any error but a problem below status 500.
+func serverError(err error) bool {
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		return err != nil
+	}
+	status, _ := p.Get("status")
+	n, _ := status.(int)
+	return n >= 500
+}
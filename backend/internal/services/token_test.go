@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	problem "github.com/cheetahbyte/problems"
+)
+
+// problemStatus is the HTTP status of a problem error, 0 for other errors.
+func problemStatus(err error) int {
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		return 0
+	}
+	status, _ := p.Get("status")
+	n, _ := status.(int)
+	return n
+}
+
+func TestValidTokenID(t *testing.T) {
+	tests := []struct {
+		jti  string
+		want bool
+	}{
+		{"AAAAAAAAAAAAAAAAAAAAAA", true},
+		{"q83vEjRWeJCrze8SNFZ4kA", true},
+		{"", false},
+		{"AAAAAAAAAAAAAAAAAAAAA", false},
+		{"AAAAAAAAAAAAAAAAAAAAAAAA", false},
+		{"AAAAAAAAAAAAAAAAAAAAAA==", false},
+		{"q83vEjRWeJCrze8SNFZ4k+", false},
+		{"q83vEjRWeJCrze8SNFZ4kB", false},
+		{"lic_1", false},
+	}
+	for _, tt := range tests {
+		if got := validTokenID(tt.jti); got != tt.want {
+			t.Errorf("validTokenID(%q) = %v, want %v", tt.jti, got, tt.want)
+		}
+	}
+}
+
+func TestRevokeTokenRejectsBadIDs(t *testing.T) {
+	svc := NewTokenService(nil, nil, nil, nil)
+
+	for _, jti := range []string{"", "x", "not a token id", "AAAAAAAAAAAAAAAAAAAAAA=="} {
+		err := svc.Revoke(context.Background(), dto.TokenRevocationRequest{JTI: jti})
+		if got := problemStatus(err); got != 400 {
+			t.Errorf("Revoke(jti %q) = %v, want a 400 problem", jti, err)
+		}
+	}
+}
+
+func TestIntrospectInvalidToken(t *testing.T) {
+	keys := licensecrypto.NewKeyring()
+	svc := NewTokenService(nil, nil, NewValidationService(nil, nil, nil, keys), keys)
+
+	for _, token := range []string{"", "not a token", "a.b.c"} {
+		got, err := svc.Introspect(context.Background(), token)
+		if err != nil || got.Active || got.LicenseClaims != nil {
+			t.Errorf("Introspect(%q) = %+v, %v; want inactive", token, got, err)
+		}
+	}
+}
+
+func TestServerError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("connection refused"), true},
+		{problem.Of(401), false},
+		{problem.Of(403), false},
+		{problem.Of(500), true},
+		{fmt.Errorf("wrapped: %w", problem.Of(503)), true},
+	}
+	for _, tt := range tests {
+		if got := serverError(tt.err); got != tt.want {
+			t.Errorf("serverError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -3,78 +3,112 @@ package services
 import (
 	"context"
 	"crypto/ed25519"
+	"errors"
 	"time"
 
 	"github.com/cheetahbyte/clave/internal/db"
 	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/cheetahbyte/clave/internal/logging"
+	"github.com/cheetahbyte/clave/internal/metrics"
+	"github.com/cheetahbyte/clave/internal/tracing"
 	problem "github.com/cheetahbyte/problems"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type ValidationService struct {
 	repo           *db.Queries
-	publicKey      ed25519.PublicKey
-	privateKey     ed25519.PrivateKey
+	keys           *licensecrypto.Keyring
 	licenseService *LicenseService
+	nonces         *NonceService
 }
 
-func NewValidationService(q *db.Queries, licenseService *LicenseService, publicKey ed25519.PublicKey, privateKey ed25519.PrivateKey) *ValidationService {
+func NewValidationService(q *db.Queries, licenseService *LicenseService, nonces *NonceService, keys *licensecrypto.Keyring) *ValidationService {
 	return &ValidationService{
 		repo:           q,
 		licenseService: licenseService,
-		publicKey:      publicKey,
-		privateKey:     privateKey,
+		nonces:         nonces,
+		keys:           keys,
 	}
 }
 
-func (svc *ValidationService) Validate(ctx context.Context, data dto.LicenseValidationRequest) (dto.LicenseValidationResponse, error) {
+// presentedToken is a token as sent by a client to /validate or
+// /heartbeat, together with the device proof for bound tokens.
+type presentedToken struct {
+	Token    string
+	DeviceID string
+	Nonce    string
+	Proof    string
+	// Components let a device whose id drifted keep its activation.
+	Components map[string]string
+}
+
+func (svc *ValidationService) Validate(ctx context.Context, data dto.LicenseValidationRequest) (_ dto.LicenseValidationResponse, err error) {
 	instance := "/licenses/validate"
+	ctx, span := tracing.Start(ctx, "ValidationService.Validate")
+	defer func() {
+		metrics.ObserveValidation(err)
+		tracing.End(span, err)
+	}()
 
-	claims, err := parseJWT(data.Token, svc.publicKey)
-	if err != nil {
-		return dto.LicenseValidationResponse{}, problem.Of(401).
-			Append(problem.Title("Invalid token")).
-			Append(problem.Instance(instance))
-	}
+	claims, license, err := svc.check(ctx, presentedToken{
+		Token:    data.Token,
+		DeviceID: data.DeviceID,
+		Nonce:    data.Nonce,
+		Proof:    data.Proof,
 
-	licenseId, err := licenseIDFromSubject(claims.Subject)
+		Components: data.Components,
+	}, instance)
 	if err != nil {
-		return dto.LicenseValidationResponse{}, problem.Of(401).
-			Append(problem.Title("Invalid token")).
-			Append(problem.Instance(instance))
+		return dto.LicenseValidationResponse{}, err
 	}
 
-	license, err := svc.repo.GetLicenseById(ctx, licenseId.Int32)
+	product, err := svc.repo.GetOneById(ctx, license.ProductID.Int32)
 	if err != nil {
 		return dto.LicenseValidationResponse{}, problem.Of(404).
-			Append(problem.Title("License not found")).
+			Append(problem.Title("Product not found")).
 			Append(problem.Instance(instance))
 	}
 
-	if license.ExpiresAt.Valid && time.Now().UTC().After(license.ExpiresAt.Time.UTC()) {
-		return dto.LicenseValidationResponse{}, problem.Of(403).
-			Append(problem.Title("License expired")).
-			Append(problem.Instance(instance))
+	if data.Nonce == "" {
+		if product.RequireValidationNonce {
+			return dto.LicenseValidationResponse{}, problem.Of(400).
+				Append(problem.Type("https://api.yourapp.dev/problems/nonce-required")).
+				Append(problem.Title("Nonce required")).
+				Append(problem.Detail("Validation requests for this product must carry a nonce")).
+				Append(problem.Instance(instance))
+		}
 	}
 
-	if data.DeviceID != "" && claims.HWID != "" && data.DeviceID != claims.HWID {
-		return dto.LicenseValidationResponse{}, problem.Of(403).
-			Append(problem.Title("HWID mismatch")).
-			Append(problem.Instance(instance))
+	// tokens are re-encrypted to the key the activation registered
+	var deviceEncKey []byte
+	if claims.HWID != "" {
+		activation, err := svc.repo.GetActivationByHwid(ctx, db.GetActivationByHwidParams{
+			LicenseID: licenseIdOf(license),
+			Hwid:      claims.HWID,
+		})
+		if err == nil {
+			deviceEncKey = activation.DeviceEncryptionKey
+		}
 	}
 
 	sevenDays := 7 * 24 * time.Hour
 	remaining := time.Until(license.ExpiresAt.Time)
 
-	newToken, _, err := svc.licenseService.issueAndSignToken(license,
-		svc.privateKey,
-		"test",
-		claims.Features,
-		claims.HWID,
-		tern(time.Now().Add(sevenDays).After(license.ExpiresAt.Time),
+	newToken, _, err := svc.licenseService.issueAndSignToken(ctx, license, tokenParams{
+		Audience: "test",
+		Features: claims.Features,
+		HWID:     claims.HWID,
+		Cnf:      claims.Cnf,
+		Nonce:    data.Nonce,
+		Format:   product.TokenFormat,
+		Alg:      product.SigningAlg,
+		TTL: tern(time.Now().Add(sevenDays).After(license.ExpiresAt.Time),
 			sevenDays,
 			remaining,
 		),
-	)
+		EncryptTo: tokenRecipient(ctx, product, deviceEncKey),
+	})
 
 	if err != nil {
 		return dto.LicenseValidationResponse{}, problem.Of(500).
@@ -87,6 +121,228 @@ func (svc *ValidationService) Validate(ctx context.Context, data dto.LicenseVali
 	}, nil
 }
 
+// Heartbeat records that a device is still running with a valid token.
+func (svc *ValidationService) Heartbeat(ctx context.Context, data dto.HeartbeatRequest) (err error) {
+	instance := "/licenses/heartbeat"
+	ctx, span := tracing.Start(ctx, "ValidationService.Heartbeat")
+	defer func() { tracing.End(span, err) }()
+
+	claims, license, err := svc.check(ctx, presentedToken{
+		Token:    data.Token,
+		DeviceID: data.DeviceID,
+		Nonce:    data.Nonce,
+		Proof:    data.Proof,
+	}, instance)
+	if err != nil {
+		return err
+	}
+
+	if claims.HWID == "" {
+		return nil
+	}
+
+	activation, err := svc.repo.GetActivationByHwid(ctx, db.GetActivationByHwidParams{
+		LicenseID: licenseIdOf(license),
+		Hwid:      claims.HWID,
+	})
+	if err == nil {
+		err = svc.repo.TouchActivation(ctx, activation.ID)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to record heartbeat", "licenseId", license.ID, "err", err)
+		return problem.Of(500).
+			Append(problem.Title("Internal error")).
+			Append(problem.Instance(instance))
+	}
+
+	return nil
+}
+
+// check verifies a presented token: everything verifyToken checks, and
+// for device bound tokens the proof of possession. It consumes the nonce
+// and moves the activation of a drifted device to its new id.
+func (svc *ValidationService) check(ctx context.Context, data presentedToken, instance string) (*licensecrypto.LicenseClaims, db.License, error) {
+	claims, license, activation, err := svc.verifyToken(ctx, data.Token, instance)
+	if err != nil {
+		return nil, db.License{}, err
+	}
+
+	drifted := false
+	if data.DeviceID != "" && claims.HWID != "" && data.DeviceID != claims.HWID {
+		if !svc.drifted(ctx, license, claims.HWID, data) {
+			return nil, db.License{}, problem.Of(403).
+				Append(problem.Title("HWID mismatch")).
+				Append(problem.Instance(instance))
+		}
+		drifted = true
+	}
+
+	// A nonce is only ever accepted once, so a captured request cannot be
+	// replayed while its token is valid.
+	if data.Nonce != "" {
+		err := svc.nonces.Consume(ctx, data.Nonce, claims.ExpiresAt.Time)
+		if err != nil && !errors.Is(err, ErrNonceInvalid) && !errors.Is(err, ErrNonceReused) {
+			logging.FromContext(ctx).Error("failed to record nonce", "licenseId", license.ID, "err", err)
+			return nil, db.License{}, problem.Of(500).
+				Append(problem.Title("Internal error")).
+				Append(problem.Instance(instance))
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("nonce rejected", "licenseId", license.ID, "err", err)
+			return nil, db.License{}, problem.Of(401).
+				Append(problem.Type("https://api.yourapp.dev/problems/invalid-nonce")).
+				Append(problem.Title("Invalid nonce")).
+				Append(problem.Detail(err.Error())).
+				Append(problem.Instance(instance))
+		}
+	}
+
+	if claims.Cnf != nil {
+		if err := svc.checkProof(claims, activation, data); err != nil {
+			logging.FromContext(ctx).Warn("device proof rejected", "licenseId", license.ID, "hwid", claims.HWID, "err", err)
+			return nil, db.License{}, problem.Of(401).
+				Append(problem.Type("https://api.yourapp.dev/problems/invalid-device-proof")).
+				Append(problem.Title("Invalid device proof")).
+				Append(problem.Detail(err.Error())).
+				Append(problem.Instance(instance))
+		}
+	}
+
+	// The device proved itself; move its activation to the new id.
+	if drifted {
+		if err := svc.licenseService.rebindActivation(ctx, activation, data.DeviceID, data.Components, nil, nil); err != nil {
+			if errors.Is(err, ErrDeviceActivated) {
+				return nil, db.License{}, deviceActivatedProblem(instance)
+			}
+			logging.FromContext(ctx).Error("failed to rebind activation", "activationId", activation.ID, "err", err)
+			return nil, db.License{}, problem.Of(500).
+				Append(problem.Title("Failed to update activation")).
+				Append(problem.Instance(instance))
+		}
+		claims.HWID = data.DeviceID
+	}
+
+	return claims, license, nil
+}
+
+// verifyToken checks what makes a token acceptable no matter who presents
+// it: signature and expiry, revocation of the token, the license being
+// active and unexpired, and the activation it was issued to still existing.
+// The activation is the zero value for tokens without a device id.
+func (svc *ValidationService) verifyToken(ctx context.Context, token, instance string) (*licensecrypto.LicenseClaims, db.License, db.Activation, error) {
+	claims, err := licensecrypto.ParseToken(token, svc.keys.Public())
+	if err != nil {
+		return nil, db.License{}, db.Activation{}, problem.Of(401).
+			Append(problem.Title("Invalid token")).
+			Append(problem.Instance(instance))
+	}
+
+	if claims.ID != "" {
+		revoked, err := svc.repo.IsRevoked(ctx, db.IsRevokedParams{Kind: db.RevocationKindToken, Subject: claims.ID})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to check token revocation", "jti", claims.ID, "err", err)
+			return nil, db.License{}, db.Activation{}, problem.Of(500).
+				Append(problem.Title("Internal error")).
+				Append(problem.Instance(instance))
+		}
+		if revoked {
+			return nil, db.License{}, db.Activation{}, problem.Of(401).
+				Append(problem.Title("Token revoked")).
+				Append(problem.Instance(instance))
+		}
+	}
+
+	licenseId, err := licenseIDFromSubject(claims.Subject)
+	if err != nil {
+		return nil, db.License{}, db.Activation{}, problem.Of(401).
+			Append(problem.Title("Invalid token")).
+			Append(problem.Instance(instance))
+	}
+
+	license, err := svc.repo.GetLicenseById(ctx, licenseId.Int32)
+	if err != nil {
+		return nil, db.License{}, db.Activation{}, problem.Of(404).
+			Append(problem.Title("License not found")).
+			Append(problem.Instance(instance))
+	}
+	logging.Annotate(ctx, "licenseId", license.ID, "productId", license.ProductID.Int32)
+
+	if license.IsActive.Valid && !license.IsActive.Bool {
+		return nil, db.License{}, db.Activation{}, problem.Of(403).
+			Append(problem.Title("License revoked")).
+			Append(problem.Instance(instance))
+	}
+
+	if license.ExpiresAt.Valid && time.Now().UTC().After(license.ExpiresAt.Time.UTC()) {
+		return nil, db.License{}, db.Activation{}, problem.Of(403).
+			Append(problem.Title("License expired")).
+			Append(problem.Instance(instance))
+	}
+
+	var activation db.Activation
+	if claims.HWID != "" {
+		activation, err = svc.repo.GetActivationByHwid(ctx, db.GetActivationByHwidParams{
+			LicenseID: licenseId,
+			Hwid:      claims.HWID,
+		})
+		if err != nil {
+			return nil, db.License{}, db.Activation{}, problem.Of(403).
+				Append(problem.Title("Activation revoked")).
+				Append(problem.Instance(instance))
+		}
+	}
+
+	return claims, license, activation, nil
+}
+
+// drifted reports whether a device presenting a token issued to another
+// device id is that device after a hardware change, by the component
+// fingerprint stored with the activation.
+func (svc *ValidationService) drifted(ctx context.Context, license db.License, hwid string, data presentedToken) bool {
+	if len(data.Components) == 0 {
+		return false
+	}
+
+	product, err := svc.repo.GetOneById(ctx, license.ProductID.Int32)
+	if err != nil {
+		return false
+	}
+	activation, err := svc.repo.GetActivationByHwid(ctx, db.GetActivationByHwidParams{
+		LicenseID: licenseIdOf(license),
+		Hwid:      hwid,
+	})
+	if err != nil {
+		return false
+	}
+
+	_, ok := findDrifted(product, []db.Activation{activation}, data.DeviceID, data.Components)
+	return ok
+}
+
+// checkProof verifies that the presenter holds the private key the token
+// is bound to, by its signature over a nonce from /nonce. A nonce the
+// device picked itself would let a stolen key sign proofs ahead of time,
+// so it is refused. The nonce has already been consumed by check.
+func (svc *ValidationService) checkProof(claims *licensecrypto.LicenseClaims, activation db.Activation, data presentedToken) error {
+	pub := ed25519.PublicKey(activation.DevicePublicKey)
+	if len(pub) != ed25519.PublicKeySize || licensecrypto.DeviceKeyThumbprint(pub) != claims.Cnf.JKT {
+		return errors.New("token is bound to an unknown device key")
+	}
+
+	if data.Nonce == "" || data.Proof == "" {
+		return errors.New("nonce and proof are required for device bound tokens")
+	}
+	if err := svc.nonces.Verify(data.Nonce); err != nil {
+		return err
+	}
+
+	return licensecrypto.VerifyDeviceProof(pub, data.Nonce, data.Token, data.Proof)
+}
+
+func licenseIdOf(license db.License) pgtype.Int4 {
+	return pgtype.Int4{Int32: license.ID, Valid: true}
+}
+
 func tern[T any](condition bool, a, b T) T {
 	if condition {
 		return a
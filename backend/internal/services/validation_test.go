@@ -0,0 +1,70 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/db"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+func TestCheckProof(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonces := NewNonceService(nil, []byte("secret"))
+	issued, err := nonces.Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := issued.Nonce
+	const token = "header.payload.signature"
+	sign := func(nonce string) string {
+		return base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, licensecrypto.DeviceProofMessage(nonce, token)))
+	}
+	proof := sign(nonce)
+
+	// a nonce the device made up, as a stolen key could sign in advance
+	ownNonce := base64.RawURLEncoding.EncodeToString([]byte("device-nonce-device-nonce"))
+	// a nonce issued by a server with another secret
+	foreign, err := NewNonceService(nil, []byte("other")).Issue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := &licensecrypto.LicenseClaims{Cnf: &licensecrypto.Confirmation{JKT: licensecrypto.DeviceKeyThumbprint(pub)}}
+
+	tests := []struct {
+		name       string
+		activation db.Activation
+		data       presentedToken
+		ok         bool
+	}{
+		{"valid", db.Activation{DevicePublicKey: pub}, presentedToken{Token: token, Nonce: nonce, Proof: proof}, true},
+		// a token bound to a key the activation does not hold, for instance
+		// after the activation was rebound to a new key
+		{"other activation key", db.Activation{DevicePublicKey: other}, presentedToken{Token: token, Nonce: nonce, Proof: proof}, false},
+		{"activation without key", db.Activation{}, presentedToken{Token: token, Nonce: nonce, Proof: proof}, false},
+		{"no proof", db.Activation{DevicePublicKey: pub}, presentedToken{Token: token, Nonce: nonce}, false},
+		{"no nonce", db.Activation{DevicePublicKey: pub}, presentedToken{Token: token, Proof: proof}, false},
+		{"other token", db.Activation{DevicePublicKey: pub}, presentedToken{Token: "other", Nonce: nonce, Proof: proof}, false},
+		{"client nonce", db.Activation{DevicePublicKey: pub}, presentedToken{Token: token, Nonce: ownNonce, Proof: sign(ownNonce)}, false},
+		{"nonce of another server", db.Activation{DevicePublicKey: pub}, presentedToken{Token: token, Nonce: foreign.Nonce, Proof: sign(foreign.Nonce)}, false},
+	}
+	svc := ValidationService{nonces: nonces}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.checkProof(claims, tt.activation, tt.data)
+			if (err == nil) != tt.ok {
+				t.Errorf("checkProof() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
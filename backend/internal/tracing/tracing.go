@@ -0,0 +1,160 @@
+// Package tracing sets up OpenTelemetry tracing for the server: spans for
+// HTTP requests, service methods and database queries, exported via OTLP.
+package tracing
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/exaring/otelpgx"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer of this module.
+const instrumentation = "github.com/cheetahbyte/clave"
+
+// RequestIDKey is the span attribute carrying middleware.RequestID, so
+// traces can be found from a request ID in logs or problem responses.
+const RequestIDKey = attribute.Key("clave.request_id")
+
+// Setup installs a tracer provider exporting over OTLP/HTTP if an OTLP
+// endpoint is configured through the standard OTEL_EXPORTER_OTLP_*
+// variables, and does nothing otherwise. The returned function flushes
+// pending spans and must be called on shutdown.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exp, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tp, err := NewProvider(ctx, exp)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// NewProvider builds a tracer provider batching spans to exp. Tests pass a
+// tracetest.InMemoryExporter and install the provider themselves.
+func NewProvider(ctx context.Context, exp sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("clave")),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+// Start starts a span as a child of the one in ctx, tagged with the
+// request ID if there is one.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if id := middleware.GetReqID(ctx); id != "" {
+		attrs = append(attrs, RequestIDKey.String(id))
+	}
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// PgxTracer traces the queries of a pgx connection or pool. Its spans
+// carry the request ID like the service spans they are nested in.
+func PgxTracer() pgx.QueryTracer {
+	return pgxTracer{otelpgx.NewTracer(otelpgx.WithSpanNameFunc(queryName))}
+}
+
+// queryName names query spans after the sqlc query, from the "-- name:"
+// comment sqlc puts first, and after the SQL operation otherwise.
+func queryName(stmt string) string {
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(stmt), "-- name: "); ok {
+		if name, _, _ := strings.Cut(rest, " "); name != "" {
+			return name
+		}
+	}
+	for word := range strings.FieldsSeq(stmt) {
+		return strings.ToUpper(word)
+	}
+	return "UNKNOWN"
+}
+
+type pgxTracer struct {
+	*otelpgx.Tracer
+}
+
+func (t pgxTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx = t.Tracer.TraceQueryStart(ctx, conn, data)
+	tagRequest(ctx)
+	return ctx
+}
+
+func (t pgxTracer) TraceBatchStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	ctx = t.Tracer.TraceBatchStart(ctx, conn, data)
+	tagRequest(ctx)
+	return ctx
+}
+
+func (t pgxTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx = t.Tracer.TraceCopyFromStart(ctx, conn, data)
+	tagRequest(ctx)
+	return ctx
+}
+
+func (t pgxTracer) TracePrepareStart(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareStartData) context.Context {
+	ctx = t.Tracer.TracePrepareStart(ctx, conn, data)
+	tagRequest(ctx)
+	return ctx
+}
+
+func (t pgxTracer) TraceConnectStart(ctx context.Context, data pgx.TraceConnectStartData) context.Context {
+	ctx = t.Tracer.TraceConnectStart(ctx, data)
+	tagRequest(ctx)
+	return ctx
+}
+
+func (t pgxTracer) TraceAcquireStart(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireStartData) context.Context {
+	ctx = t.Tracer.TraceAcquireStart(ctx, pool, data)
+	tagRequest(ctx)
+	return ctx
+}
+
+// tagRequest sets the request ID on the span in ctx, if there are both.
+func tagRequest(ctx context.Context) {
+	if id := middleware.GetReqID(ctx); id != "" {
+		trace.SpanFromContext(ctx).SetAttributes(RequestIDKey.String(id))
+	}
+}
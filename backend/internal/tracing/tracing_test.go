@@ -0,0 +1,21 @@
+package tracing
+
+import "testing"
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{"-- name: GetOneById :one\nselect * from products where id = $1", "GetOneById"},
+		{"\n-- name: ListRevocationsSince :many\nselect 1", "ListRevocationsSince"},
+		{"select 1", "SELECT"},
+		{"  insert into t values ($1)", "INSERT"},
+		{"", "UNKNOWN"},
+	}
+	for _, tt := range tests {
+		if got := queryName(tt.stmt); got != tt.want {
+			t.Errorf("queryName(%q) = %q, want %q", tt.stmt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS license_batches (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    size INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE licenses
+    ADD COLUMN batch_id INTEGER REFERENCES license_batches(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_licenses_batch_id ON licenses(batch_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_licenses_batch_id;
+
+ALTER TABLE licenses
+    DROP COLUMN batch_id;
+
+DROP TABLE IF EXISTS license_batches;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products
+    ADD COLUMN key_prefix TEXT,
+    ADD COLUMN key_bytes INTEGER NOT NULL DEFAULT 15,
+    ADD COLUMN key_group_size INTEGER NOT NULL DEFAULT 4;
+
+CREATE UNIQUE INDEX products_key_prefix_uq
+    ON products (key_prefix);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS products_key_prefix_uq;
+
+ALTER TABLE products
+    DROP COLUMN key_prefix,
+    DROP COLUMN key_bytes,
+    DROP COLUMN key_group_size;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE license_key_type AS ENUM ('random', 'signed');
+
+ALTER TABLE licenses
+    ADD COLUMN key_type license_key_type NOT NULL DEFAULT 'random',
+    ADD COLUMN features TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE licenses
+    DROP COLUMN key_type,
+    DROP COLUMN features;
+
+DROP TYPE IF EXISTS license_key_type;
+-- +goose StatementEnd
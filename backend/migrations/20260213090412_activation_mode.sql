@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE activation_mode AS ENUM ('online', 'offline', 'imported');
+
+ALTER TABLE activations
+    ADD COLUMN mode activation_mode NOT NULL DEFAULT 'online';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE activations
+    DROP COLUMN mode;
+
+DROP TYPE IF EXISTS activation_mode;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE revocation_kind AS ENUM ('license', 'activation', 'token');
+
+CREATE TABLE IF NOT EXISTS revocations (
+    id BIGSERIAL PRIMARY KEY,
+    kind revocation_kind NOT NULL,
+    subject TEXT NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(kind, subject)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS revocations;
+DROP TYPE IF EXISTS revocation_kind;
+-- +goose StatementEnd
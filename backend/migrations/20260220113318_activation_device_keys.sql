@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE activations
+    ADD COLUMN device_public_key BYTEA;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE activations
+    DROP COLUMN device_public_key;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE token_format AS ENUM ('jwt', 'paseto_v4', 'cwt');
+
+ALTER TABLE products
+    ADD COLUMN token_format token_format NOT NULL DEFAULT 'jwt';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE products
+    DROP COLUMN token_format;
+
+DROP TYPE IF EXISTS token_format;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE signing_alg AS ENUM ('EdDSA', 'ES256', 'RS256');
+
+ALTER TABLE products
+    ADD COLUMN signing_alg signing_alg NOT NULL DEFAULT 'EdDSA';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE products
+    DROP COLUMN signing_alg;
+
+DROP TYPE IF EXISTS signing_alg;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products
+    ADD COLUMN token_encryption_key BYTEA;
+
+ALTER TABLE activations
+    ADD COLUMN device_encryption_key BYTEA;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE activations
+    DROP COLUMN device_encryption_key;
+
+ALTER TABLE products
+    DROP COLUMN token_encryption_key;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE activations
+    ADD COLUMN components JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE products
+    ADD COLUMN hwid_match_threshold INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS activation_drift (
+    id BIGSERIAL PRIMARY KEY,
+    activation_id INTEGER NOT NULL REFERENCES activations(id) ON DELETE CASCADE,
+    old_hwid TEXT NOT NULL,
+    new_hwid TEXT NOT NULL,
+    matched INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    changed TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_activation_drift_activation ON activation_drift(activation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS activation_drift;
+
+ALTER TABLE products
+    DROP COLUMN hwid_match_threshold;
+
+ALTER TABLE activations
+    DROP COLUMN components;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Existing hashes were taken over the key as issued; they are rehashed in
+-- normalized form the next time their key is presented.
+ALTER TABLE licenses
+    ADD COLUMN key_normalized BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE licenses
+    ALTER COLUMN key_normalized SET DEFAULT true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE licenses
+    DROP COLUMN key_normalized;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Keys are routed by the canonical form of their prefix, where O reads as 0
+-- and I and L as 1, so prefixes must be unique in that form.
+DROP INDEX IF EXISTS products_key_prefix_uq;
+
+CREATE UNIQUE INDEX products_key_prefix_uq
+    ON products (translate(upper(key_prefix), 'OIL', '011'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS products_key_prefix_uq;
+
+CREATE UNIQUE INDEX products_key_prefix_uq
+    ON products (key_prefix);
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Revocation list versions come from a single counter row. Revoking
+-- transactions bump it and hold its lock until they commit, so versions
+-- become visible in order and clients polling with ?since= never skip an
+-- entry committed late, as they could with sequence ids.
+CREATE TABLE IF NOT EXISTS revocation_counter (
+    id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    version BIGINT NOT NULL
+);
+
+INSERT INTO revocation_counter (version)
+SELECT coalesce(max(id), 0) FROM revocations;
+
+ALTER TABLE revocations ADD COLUMN version BIGINT;
+UPDATE revocations SET version = id;
+ALTER TABLE revocations ALTER COLUMN version SET NOT NULL;
+
+CREATE UNIQUE INDEX revocations_version_uq ON revocations (version);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS revocations_version_uq;
+ALTER TABLE revocations DROP COLUMN IF EXISTS version;
+DROP TABLE IF EXISTS revocation_counter;
+-- +goose StatementEnd
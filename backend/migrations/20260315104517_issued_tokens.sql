@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Token ids are recorded as tokens are issued, so revoking by jti can tell
+-- a real token from a typo.
+CREATE TABLE IF NOT EXISTS issued_tokens (
+    jti TEXT PRIMARY KEY,
+    license_id INTEGER NOT NULL REFERENCES licenses(id) ON DELETE CASCADE,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS issued_tokens_expires_at_idx ON issued_tokens (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS issued_tokens;
+-- +goose StatementEnd
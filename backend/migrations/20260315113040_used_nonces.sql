@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Nonces clients sent with validation and heartbeat requests. They are kept
+-- until the token they came with expires; a replay after that fails on the
+-- token itself.
+CREATE TABLE IF NOT EXISTS used_nonces (
+    nonce TEXT PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS used_nonces_expires_at_idx ON used_nonces (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS used_nonces;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Prefixes of keys imported from other licensing systems. Such keys carry no
+-- check group, so their prefix is the only way to tell them from typos.
+ALTER TABLE products
+    ADD COLUMN imported_key_prefixes TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE products
+    DROP COLUMN imported_key_prefixes;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+// Package migrations embeds the goose migrations of the database schema.
+// The sqlc code in internal/db is generated from the same files, so the
+// newest migration is the schema version the binary was built for.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+var (
+	// ErrSchemaOutdated means the database lacks migrations the binary
+	// needs.
+	ErrSchemaOutdated = errors.New("database schema is outdated, run clave migrate up")
+	// ErrSchemaTooNew means the database was migrated by a newer release.
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+)
+
+// NewProvider returns a goose provider for the embedded migrations. Runs
+// take a Postgres advisory lock, so servers migrating at startup do not
+// race each other.
+func NewProvider(db *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db, files, goose.WithSessionLocker(locker))
+}
+
+// Check compares the database's schema version with the embedded one.
+func Check(ctx context.Context, p *goose.Provider) error {
+	current, target, err := p.GetVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	switch {
+	case current < target:
+		return fmt.Errorf("%w (at %d, want %d)", ErrSchemaOutdated, current, target)
+	case current > target:
+		return fmt.Errorf("%w (at %d, want %d)", ErrSchemaTooNew, current, target)
+	}
+	return nil
+}
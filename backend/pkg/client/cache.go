@@ -0,0 +1,137 @@
+package client
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+// cacheVersion is the first byte of cache files. Version 1 files were
+// sealed with a key derived from the device id and product, which anyone
+// can compute; they are ignored.
+const cacheVersion = 2
+
+// minCacheKeySize is the shortest Config.CacheKey accepted.
+const minCacheKeySize = 16
+
+// ErrCacheTampered means the cache file does not decrypt with the cache key,
+// because it was modified or written for another device or key.
+var ErrCacheTampered = errors.New("clave: token cache has been tampered with")
+
+// cacheEntry is what the client persists between runs.
+type cacheEntry struct {
+	Token string `json:"token"`
+	// ServerTime is the highest signed server time seen, in unix
+	// milliseconds.
+	ServerTime int64 `json:"serverTime"`
+	// Keys are the fetched server keys, so the token can be verified
+	// offline when keys are not pinned.
+	Keys *licensecrypto.JWKSet `json:"keys,omitempty"`
+	// PendingNonce is the nonce of an offline activation request whose
+	// response has not been imported yet.
+	PendingNonce string `json:"pendingNonce,omitempty"`
+	// ActivationID identifies the activation on the revocation list.
+	ActivationID int32 `json:"activationId,omitempty"`
+	// RevocationVersion is the revocation list version last applied.
+	RevocationVersion int64 `json:"revocationVersion,omitempty"`
+}
+
+// cacheKey is derived from Config.CacheKey, or from the device key if
+// that is nil. Both are secrets of the host, so only the host can write a
+// cache file the client accepts.
+func (c *Client) cacheKey() []byte {
+	h := sha256.New()
+	h.Write([]byte("clave-cache\n"))
+	if c.cfg.CacheKey != nil {
+		h.Write(c.cfg.CacheKey)
+	} else {
+		h.Write(c.cfg.DeviceKey.Seed())
+	}
+	return h.Sum(nil)
+}
+
+// cacheAEAD seals cache files with AES-256-GCM; the version byte is
+// authenticated as well.
+func (c *Client) cacheAEAD() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.cacheKey())
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *Client) readCache() (cacheEntry, error) {
+	raw, err := os.ReadFile(c.cfg.CachePath)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	aead, err := c.cacheAEAD()
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	if len(raw) > 0 && raw[0] < cacheVersion {
+		// the device activates again
+		return cacheEntry{}, fmt.Errorf("outdated token cache: %w", os.ErrNotExist)
+	}
+	if len(raw) < 1+aead.NonceSize() || raw[0] != cacheVersion {
+		return cacheEntry{}, ErrCacheTampered
+	}
+	nonce, sealed := raw[1:1+aead.NonceSize()], raw[1+aead.NonceSize():]
+
+	plain, err := aead.Open(nil, nonce, sealed, raw[:1])
+	if err != nil {
+		return cacheEntry{}, ErrCacheTampered
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(plain, &e); err != nil {
+		return cacheEntry{}, ErrCacheTampered
+	}
+	return e, nil
+}
+
+// writeCache replaces the cache file atomically.
+func (c *Client) writeCache(e cacheEntry) error {
+	plain, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	aead, err := c.cacheAEAD()
+	if err != nil {
+		return err
+	}
+	out := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(plain)+aead.Overhead())
+	out[0] = cacheVersion
+	if _, err := rand.Read(out[1:]); err != nil {
+		return err
+	}
+	out = aead.Seal(out, out[1:], plain, out[:1])
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.cfg.CachePath), ".clave-token-*")
+	if err != nil {
+		return fmt.Errorf("clave: writing token cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return fmt.Errorf("clave: writing token cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("clave: writing token cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.cfg.CachePath); err != nil {
+		return fmt.Errorf("clave: writing token cache: %w", err)
+	}
+	return nil
+}
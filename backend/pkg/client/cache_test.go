@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewRequiresCacheSecret(t *testing.T) {
+	_, deviceKey, _ := ed25519.GenerateKey(nil)
+	path := filepath.Join(t.TempDir(), "token")
+
+	tests := []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"no cache", Config{}, true},
+		{"cache key", Config{CachePath: path, CacheKey: testCacheKey}, true},
+		{"device key", Config{CachePath: path, DeviceKey: deviceKey}, true},
+		{"no secret", Config{CachePath: path}, false},
+		{"short cache key", Config{CachePath: path, CacheKey: []byte("secret")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.BaseURL, tt.cfg.DeviceID = "http://localhost", "device-1"
+			if _, err := New(tt.cfg); (err == nil) != tt.ok {
+				t.Errorf("New() = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCacheSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	_, deviceKey, _ := ed25519.GenerateKey(nil)
+
+	for name, cfg := range map[string]Config{
+		"cache key":  {CacheKey: testCacheKey},
+		"device key": {DeviceKey: deviceKey},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg.Keys = []crypto.PublicKey{s.public()}
+			cfg.CachePath = filepath.Join(t.TempDir(), "token")
+
+			c := newTestClient(t, s, cfg)
+			activated, err := c.Activate(ctx, "LIC-0000-0000")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c = newTestClient(t, s, cfg)
+			claims, err := c.Claims(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.TokenID != activated.TokenID {
+				t.Errorf("cached token %q, want %q", claims.TokenID, activated.TokenID)
+			}
+		})
+	}
+}
+
+func TestCacheRejectsForgery(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	cfg := Config{
+		Keys:      []crypto.PublicKey{s.public()},
+		CachePath: filepath.Join(t.TempDir(), "token"),
+		CacheKey:  testCacheKey,
+	}
+
+	c := newTestClient(t, s, cfg)
+	if _, err := c.Activate(ctx, "LIC-0000-0000"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a cache written by someone who knows everything but the secret, the
+	// way the key used to be derived
+	forger := newTestClient(t, s, Config{CachePath: cfg.CachePath, CacheKey: []byte("device-1\n0-guessed-key")})
+	forger.token = "forged"
+	if err := forger.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = newTestClient(t, s, cfg)
+	if _, err := c.Claims(ctx); !errors.Is(err, ErrCacheTampered) {
+		t.Errorf("Claims() with a forged cache = %v, want %v", err, ErrCacheTampered)
+	}
+}
+
+func TestCacheIgnoresVersion1(t *testing.T) {
+	s := newFakeServer(t)
+	cfg := Config{CachePath: filepath.Join(t.TempDir(), "token"), CacheKey: testCacheKey}
+
+	// version 1 files were sealed with sha256("clave-cache\n" + device id
+	// and product id)
+	sum := sha256.Sum256([]byte("clave-cache\ndevice-1\n0"))
+	if err := os.WriteFile(cfg.CachePath, append([]byte{1}, sum[:]...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t, s, cfg)
+	if _, err := c.Claims(context.Background()); !errors.Is(err, ErrNotActivated) {
+		t.Errorf("Claims() with a version 1 cache = %v, want %v", err, ErrNotActivated)
+	}
+}
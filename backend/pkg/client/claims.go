@@ -0,0 +1,62 @@
+package client
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+// Claims are the verified claims of a license token.
+type Claims struct {
+	// LicenseID is the server's id of the license.
+	LicenseID int32
+	ProductID int32
+	// DeviceID is the device the token was issued to, empty if the token
+	// is not tied to one.
+	DeviceID string
+	Features []string
+	// LicenseExpiresAt is when the license itself runs out, the zero time
+	// for perpetual licenses. Tokens expire long before that and are
+	// refreshed.
+	LicenseExpiresAt time.Time
+
+	// TokenID identifies the token, for instance to revoke it.
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// HasFeature reports whether the license grants feature.
+func (c *Claims) HasFeature(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// claimsOf converts verified token claims to Claims, passing err through.
+func claimsOf(lc *licensecrypto.LicenseClaims, err error) (*Claims, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Claims{
+		ProductID: lc.ProductID,
+		DeviceID:  lc.HWID,
+		Features:  slices.Clone(lc.Features),
+		TokenID:   lc.ID,
+	}
+	if id, err := strconv.ParseInt(strings.TrimPrefix(lc.Subject, "lic_"), 10, 32); err == nil {
+		c.LicenseID = int32(id)
+	}
+	if lc.LicenseExp != nil {
+		c.LicenseExpiresAt = time.Unix(*lc.LicenseExp, 0)
+	}
+	if lc.IssuedAt != nil {
+		c.IssuedAt = lc.IssuedAt.Time
+	}
+	if lc.ExpiresAt != nil {
+		c.ExpiresAt = lc.ExpiresAt.Time
+	}
+	return c, nil
+}
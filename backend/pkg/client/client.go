@@ -0,0 +1,549 @@
+package client
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultRefreshBefore is how long before expiry Token refreshes a token
+// when Config.RefreshBefore is unset.
+const defaultRefreshBefore = 5 * time.Minute
+
+var (
+	// ErrNotActivated means there is no token yet; call Activate.
+	ErrNotActivated = errors.New("clave: license is not activated on this device")
+	// ErrNonceMismatch means a refreshed token did not echo the nonce sent
+	// with the request, which points to a replayed response.
+	ErrNonceMismatch = errors.New("clave: token does not echo the request nonce")
+	// ErrDeviceMismatch means a token was issued for another device.
+	ErrDeviceMismatch = errors.New("clave: token was issued for another device")
+	// ErrInvalidToken means a token is malformed, cannot be decrypted or
+	// is not signed by any of the server's keys.
+	ErrInvalidToken = errors.New("clave: invalid license token")
+)
+
+type Config struct {
+	// BaseURL is the server root, e.g. https://licenses.example.com.
+	BaseURL   string
+	ProductID int32
+	DeviceID  string
+	// Components are the hashed hardware components DeviceID was derived
+	// from, see fingerprint.Fingerprint. Sent along, they let products with
+	// a match threshold recognise the device after some hardware changed.
+	Components map[string]string
+
+	// Keys pins the server's public signing keys. If empty they are fetched
+	// from the server's JWKS, which needs the server to be reachable before
+	// the first verification.
+	Keys []crypto.PublicKey
+
+	// CachePath is the file the current token is kept in between runs. No
+	// cache is kept if empty. The file is encrypted and authenticated with
+	// CacheKey, a secret of at least 16 bytes the host keeps, for instance
+	// in the OS keychain. Without a CacheKey the cache key is derived from
+	// DeviceKey; one of the two is required for a cache.
+	CachePath string
+	CacheKey  []byte
+
+	// OfflineGrace is how long an expired token stays usable while the
+	// server cannot be reached.
+	OfflineGrace time.Duration
+	// OnStateChange is called when the license state changes, for instance
+	// from online to offline when a refresh fails, and on to grace once the
+	// token has expired.
+	OnStateChange func(from, to State)
+
+	// DeviceKey binds tokens to the device. Refreshes then carry a proof of
+	// possession signed with it, and activations a proof that lets the
+	// server keep the device's seat when its DeviceID changes with its
+	// hardware.
+	DeviceKey ed25519.PrivateKey
+	// DecryptionKey is the P-256 or RSA private key encrypted tokens are
+	// opened with. Its public half is registered on activation.
+	DecryptionKey crypto.PrivateKey
+
+	// RefreshBefore is how long before expiry Token fetches a new token.
+	RefreshBefore time.Duration
+	HTTPClient    *http.Client
+}
+
+// Client talks to a clave server on behalf of one device.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu     sync.Mutex
+	keys   licensecrypto.KeySet
+	token  string
+	claims *licensecrypto.LicenseClaims
+	// fetched are keys from the server's JWKS, cached with the token.
+	fetched *licensecrypto.JWKSet
+	// serverTime is the highest signed server time seen, unix ms.
+	serverTime int64
+	// pendingNonce is the nonce of the last OfflineRequest.
+	pendingNonce string
+	// activationID is the server's id for this device's activation.
+	activationID int32
+	// revocationVersion is the revocation list version last applied.
+	revocationVersion int64
+	state             State
+	changes           []stateChange
+}
+
+func New(cfg Config) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, errors.New("clave: BaseURL is required")
+	}
+	if cfg.DeviceID == "" {
+		return nil, errors.New("clave: DeviceID is required")
+	}
+	if cfg.DeviceKey != nil && len(cfg.DeviceKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("clave: invalid ed25519 device key size")
+	}
+	if cfg.CacheKey != nil && len(cfg.CacheKey) < minCacheKeySize {
+		return nil, fmt.Errorf("clave: CacheKey must be at least %d bytes", minCacheKeySize)
+	}
+	if cfg.CachePath != "" && cfg.CacheKey == nil && cfg.DeviceKey == nil {
+		return nil, errors.New("clave: CachePath requires a CacheKey or DeviceKey")
+	}
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = defaultRefreshBefore
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	c := &Client{cfg: cfg, http: cfg.HTTPClient}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	keys, err := licensecrypto.NewKeySet(cfg.Keys...)
+	if err != nil {
+		return nil, fmt.Errorf("clave: %v", err)
+	}
+	c.keys = keys
+
+	return c, nil
+}
+
+// Activate activates the license key on this device and stores the first
+// token.
+func (c *Client) Activate(ctx context.Context, licenseKey string) (*Claims, error) {
+	req, err := c.activationRequest(licenseKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp activateResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/activate", req, &resp); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+	activationID := c.activationID
+	c.activationID = resp.ActivationID
+	claims, err := c.accept(ctx, resp.Token, "")
+	if err != nil {
+		c.activationID = activationID
+	}
+	return claimsOf(claims, err)
+}
+
+// activationRequest describes this device to the server.
+func (c *Client) activationRequest(licenseKey string) (activateRequest, error) {
+	req := activateRequest{
+		LicenseKey: licenseKey,
+		DeviceID:   c.cfg.DeviceID,
+		ProductID:  c.cfg.ProductID,
+		Components: c.cfg.Components,
+	}
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return req, err
+	}
+	req.Nonce = base64.RawURLEncoding.EncodeToString(nonce)
+
+	// The proof lets the server move this device's activation to its new
+	// id if some of its hardware changed.
+	if c.cfg.DeviceKey != nil {
+		pub := c.cfg.DeviceKey.Public().(ed25519.PublicKey)
+		req.DevicePublicKey = base64.StdEncoding.EncodeToString(pub)
+		sig := ed25519.Sign(c.cfg.DeviceKey, licensecrypto.RebindProofMessage(req.Nonce, req.DeviceID, pub))
+		req.Proof = base64.RawURLEncoding.EncodeToString(sig)
+	}
+	if c.cfg.DecryptionKey != nil {
+		k, ok := c.cfg.DecryptionKey.(interface{ Public() crypto.PublicKey })
+		if !ok {
+			return req, fmt.Errorf("clave: unsupported decryption key type %T", c.cfg.DecryptionKey)
+		}
+		der, err := x509.MarshalPKIXPublicKey(k.Public())
+		if err != nil {
+			return req, fmt.Errorf("clave: %w", err)
+		}
+		req.DeviceEncryptionKey = base64.StdEncoding.EncodeToString(der)
+	}
+	return req, nil
+}
+
+// Refresh exchanges the current token for a new one, which also tells the
+// client about revocations. Each refresh carries a fresh nonce the new
+// token has to echo.
+func (c *Client) Refresh(ctx context.Context) (*Claims, error) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if err := c.load(ctx); err != nil {
+		return nil, err
+	}
+	return claimsOf(c.refresh(ctx))
+}
+
+// Token returns a valid token for the device, refreshing it when it is
+// about to expire. If the server cannot be reached the current token is
+// returned until it expires, and after that for Config.OfflineGrace. A
+// refusal by the server, such as a revocation, is returned as *Problem and
+// drops the token.
+func (c *Client) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if err := c.load(ctx); err != nil {
+		return "", err
+	}
+	if err := c.checkClock(); err != nil {
+		return "", err
+	}
+
+	exp := c.claims.ExpiresAt.Time
+	if time.Until(exp) > c.cfg.RefreshBefore {
+		c.setState(StateOnline)
+		return c.token, nil
+	}
+
+	_, err := c.refresh(ctx)
+	switch {
+	case err == nil:
+		return c.token, nil
+	case c.token == "":
+		return "", err
+	case time.Now().Before(exp):
+		c.setState(StateOffline)
+		return c.token, nil
+	case time.Now().Before(exp.Add(c.cfg.OfflineGrace)):
+		c.setState(StateGrace)
+		return c.token, nil
+	default:
+		c.setState(StateExpired)
+		return "", fmt.Errorf("%w: %w", ErrTokenExpired, err)
+	}
+}
+
+// State returns the license state as of the last call.
+func (c *Client) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Claims returns the verified claims of the current token.
+func (c *Client) Claims(ctx context.Context) (*Claims, error) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if err := c.load(ctx); err != nil {
+		return nil, err
+	}
+	return claimsOf(c.claims, nil)
+}
+
+// Heartbeat tells the server the device is still running.
+func (c *Client) Heartbeat(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if err := c.load(ctx); err != nil {
+		return err
+	}
+
+	req := heartbeatRequest{Token: c.token, DeviceID: c.cfg.DeviceID}
+	if err := c.prove(ctx, &req.Nonce, &req.Proof); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, "/api/v1/heartbeat", req, nil)
+}
+
+// Verify checks a token offline: signature, expiry and device. Encrypted
+// tokens are decrypted first.
+func (c *Client) Verify(ctx context.Context, token string) (*Claims, error) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	_, claims, err := c.verify(ctx, token)
+	return claimsOf(claims, err)
+}
+
+// load makes sure a token is at hand, reading the cache if need be. Only
+// the signature of a cached token is checked here; its lifetime is up to
+// Token, which knows about the grace window. Callers hold c.mu.
+func (c *Client) load(ctx context.Context) error {
+	if c.token != "" {
+		return nil
+	}
+	if c.cfg.CachePath == "" {
+		return ErrNotActivated
+	}
+
+	e, err := c.readCache()
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotActivated
+	}
+	if errors.Is(err, ErrCacheTampered) {
+		c.setState(StateExpired)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("clave: reading token cache: %w", err)
+	}
+
+	if e.Keys != nil && len(c.cfg.Keys) == 0 {
+		c.fetched, c.keys = e.Keys, e.Keys.KeySet()
+	}
+	c.serverTime = e.ServerTime
+	c.pendingNonce = cmp.Or(c.pendingNonce, e.PendingNonce)
+	c.activationID = cmp.Or(c.activationID, e.ActivationID)
+	c.revocationVersion = max(c.revocationVersion, e.RevocationVersion)
+	if e.Token == "" {
+		return ErrNotActivated
+	}
+
+	token, claims, err := c.decode(ctx, e.Token)
+	if err != nil {
+		return fmt.Errorf("clave: cached token: %w", err)
+	}
+	c.token, c.claims = token, claims
+	return nil
+}
+
+// checkClock catches a clock set back below the last signed server time,
+// with some slack for clocks that are merely a little off.
+func (c *Client) checkClock() error {
+	const skew = 5 * time.Minute
+
+	if c.serverTime != 0 && time.Now().Before(time.UnixMilli(c.serverTime).Add(-skew)) {
+		c.setState(StateExpired)
+		return ErrClockRollback
+	}
+	return nil
+}
+
+func (c *Client) refresh(ctx context.Context) (*licensecrypto.LicenseClaims, error) {
+	req := validationRequest{Token: c.token, DeviceID: c.cfg.DeviceID, Components: c.cfg.Components}
+	if err := c.prove(ctx, &req.Nonce, &req.Proof); err != nil {
+		return nil, err
+	}
+
+	var resp validationResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/validate", req, &resp); err != nil {
+		if refused(err) {
+			c.drop()
+			c.setState(StateExpired)
+		}
+		return nil, err
+	}
+	return c.accept(ctx, resp.Token, req.Nonce)
+}
+
+// prove sets the request's nonce and, for device bound tokens, signs the
+// proof of possession over it and the current token. The server only
+// accepts proofs over nonces it issued, so bound clients fetch one from
+// /nonce; others generate their own.
+func (c *Client) prove(ctx context.Context, nonce, proof *string) error {
+	if c.cfg.DeviceKey == nil {
+		n := make([]byte, 32)
+		if _, err := rand.Read(n); err != nil {
+			return err
+		}
+		*nonce = base64.RawURLEncoding.EncodeToString(n)
+		return nil
+	}
+
+	var resp nonceResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/nonce", nil, &resp); err != nil {
+		return err
+	}
+	*nonce = resp.Nonce
+
+	sig := ed25519.Sign(c.cfg.DeviceKey, licensecrypto.DeviceProofMessage(*nonce, c.token))
+	*proof = base64.RawURLEncoding.EncodeToString(sig)
+	return nil
+}
+
+// accept verifies a token from the server and makes it current.
+func (c *Client) accept(ctx context.Context, token, nonce string) (*licensecrypto.LicenseClaims, error) {
+	token, claims, err := c.verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if nonce != "" && claims.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	c.token, c.claims = token, claims
+	c.serverTime = max(c.serverTime, claims.ServerTime)
+	c.setState(StateOnline)
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verify decrypts and fully verifies a token, returning the signed token
+// and its claims.
+func (c *Client) verify(ctx context.Context, token string) (string, *licensecrypto.LicenseClaims, error) {
+	token, claims, err := c.decode(ctx, token)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := jwt.NewValidator().Validate(claims); err != nil {
+		return "", nil, fmt.Errorf("clave: %w", err)
+	}
+	return token, claims, nil
+}
+
+// decode decrypts a token and checks its signature and device, but not its
+// lifetime. An unknown key id triggers one JWKS refetch when keys are not
+// pinned.
+func (c *Client) decode(ctx context.Context, token string) (string, *licensecrypto.LicenseClaims, error) {
+	if licensecrypto.IsEncrypted(token) {
+		if c.cfg.DecryptionKey == nil {
+			return "", nil, errors.New("clave: token is encrypted but no DecryptionKey is configured")
+		}
+		inner, err := licensecrypto.DecryptToken(token, c.cfg.DecryptionKey)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		token = inner
+	}
+
+	if len(c.keys) == 0 {
+		if err := c.fetchKeys(ctx); err != nil {
+			return "", nil, err
+		}
+	}
+
+	claims, err := licensecrypto.DecodeToken(token, c.keys)
+	if errors.Is(err, licensecrypto.ErrUnknownKey) && len(c.cfg.Keys) == 0 {
+		if err := c.fetchKeys(ctx); err != nil {
+			return "", nil, err
+		}
+		claims, err = licensecrypto.DecodeToken(token, c.keys)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.HWID != "" && claims.HWID != c.cfg.DeviceID {
+		return "", nil, ErrDeviceMismatch
+	}
+	return token, claims, nil
+}
+
+func (c *Client) fetchKeys(ctx context.Context) error {
+	var set licensecrypto.JWKSet
+	if err := c.do(ctx, http.MethodGet, "/.well-known/jwks.json", nil, &set); err != nil {
+		return err
+	}
+	c.fetched, c.keys = &set, set.KeySet()
+	return nil
+}
+
+// save writes the current token to the cache file.
+func (c *Client) save() error {
+	if c.cfg.CachePath == "" {
+		return nil
+	}
+	return c.writeCache(cacheEntry{
+		Token:             c.token,
+		ServerTime:        c.serverTime,
+		Keys:              c.fetched,
+		PendingNonce:      c.pendingNonce,
+		ActivationID:      c.activationID,
+		RevocationVersion: c.revocationVersion,
+	})
+}
+
+// refused reports whether the server rejected the token itself, as opposed
+// to the request around it.
+func refused(err error) bool {
+	var p *Problem
+	if !errors.As(err, &p) {
+		return false
+	}
+	switch p.Type {
+	case ProblemInvalidNonce, ProblemInvalidDeviceProof, ProblemNonceRequired:
+		return false
+	}
+	return p.Status == http.StatusUnauthorized || p.Status == http.StatusForbidden
+}
+
+// drop forgets the current token after the server refused it.
+func (c *Client) drop() {
+	c.token, c.claims = "", nil
+	if c.cfg.CachePath != "" {
+		_ = os.Remove(c.cfg.CachePath)
+	}
+}
+
+// do sends a JSON request and decodes the JSON response into out. Error
+// responses are returned as *Problem.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.BaseURL+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("clave: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return readProblem(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("clave: decoding %s response: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeServer plays the clave API for one license: it activates any device
+// and refreshes tokens it issued.
+type fakeServer struct {
+	*httptest.Server
+	t    *testing.T
+	priv ed25519.PrivateKey
+	key  licensecrypto.SigningKey
+
+	mu sync.Mutex
+	// ttl is the lifetime of issued tokens.
+	ttl time.Duration
+	// refuse, if set, is returned for validation requests.
+	refuse *Problem
+	// down makes every request fail with 503.
+	down bool
+	// nonces are the nonces validation requests carried.
+	nonces []string
+	// issued are the nonces handed out by /nonce.
+	issued []string
+	// replay, if set, answers validation requests with this token.
+	replay string
+	// revoked is the revocation list, in version order.
+	revoked []licensecrypto.RevocationEntry
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := licensecrypto.NewSigningKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeServer{t: t, priv: priv, key: key, ttl: time.Hour}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/activate", s.activate)
+	mux.HandleFunc("POST /api/v1/validate", s.validate)
+	mux.HandleFunc("POST /api/v1/nonce", s.nonce)
+	mux.HandleFunc("GET /api/v1/revocations", s.revocations)
+	mux.HandleFunc("GET /.well-known/jwks.json", s.jwks)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		down := s.down
+		s.mu.Unlock()
+		if down {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeServer) public() crypto.PublicKey {
+	return s.priv.Public()
+}
+
+func (s *fakeServer) setDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+// token issues a license token for hwid that echoes nonce.
+func (s *fakeServer) token(hwid, nonce string, ttl time.Duration) string {
+	s.t.Helper()
+
+	now := time.Now()
+	jti := make([]byte, 8)
+	_, _ = rand.Read(jti)
+	claims := &licensecrypto.LicenseClaims{
+		ProductID:  1,
+		HWID:       hwid,
+		Features:   []string{"pro"},
+		Nonce:      nonce,
+		ServerTime: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        base64.RawURLEncoding.EncodeToString(jti),
+			Subject:   "lic_1",
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now.Add(-30 * time.Second)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	signed, err := licensecrypto.SignToken(claims, licensecrypto.FormatJWT, s.key)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return signed
+}
+
+func (s *fakeServer) activate(w http.ResponseWriter, r *http.Request) {
+	var req dto.ActivateLicenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	ttl := s.ttl
+	s.mu.Unlock()
+	writeTestJSON(w, dto.ActivateLicenseResponse{ActivationId: 1, Token: s.token(req.DeviceID, "", ttl)})
+}
+
+func (s *fakeServer) validate(w http.ResponseWriter, r *http.Request) {
+	var req dto.LicenseValidationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	ttl, refuse, replay := s.ttl, s.refuse, s.replay
+	s.nonces = append(s.nonces, req.Nonce)
+	s.mu.Unlock()
+	if refuse != nil {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(refuse.Status)
+		_ = json.NewEncoder(w).Encode(refuse)
+		return
+	}
+	if _, err := licensecrypto.DecodeToken(req.Token, licensecrypto.KeySet{{ID: s.key.ID, Alg: s.key.Alg, Key: s.public()}}); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if replay != "" {
+		writeTestJSON(w, dto.LicenseValidationResponse{Token: replay})
+		return
+	}
+	writeTestJSON(w, dto.LicenseValidationResponse{Token: s.token(req.DeviceID, req.Nonce, ttl)})
+}
+
+func (s *fakeServer) nonce(w http.ResponseWriter, _ *http.Request) {
+	n := make([]byte, 40)
+	_, _ = rand.Read(n)
+	nonce := base64.RawURLEncoding.EncodeToString(n)
+
+	s.mu.Lock()
+	s.issued = append(s.issued, nonce)
+	s.mu.Unlock()
+	writeTestJSON(w, nonceResponse{Nonce: nonce})
+}
+
+// revoke publishes a revocation under the next version.
+func (s *fakeServer) revoke(kind, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked = append(s.revoked, licensecrypto.RevocationEntry{
+		Version:   int64(len(s.revoked) + 1),
+		Kind:      kind,
+		ID:        id,
+		RevokedAt: time.Now().Unix(),
+	})
+}
+
+func (s *fakeServer) revocations(w http.ResponseWriter, r *http.Request) {
+	since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+
+	s.mu.Lock()
+	latest := int64(len(s.revoked))
+	if since > latest {
+		since = 0
+	}
+	claims := &licensecrypto.RevocationListClaims{
+		Version:     latest,
+		Since:       since,
+		Revocations: slices.Clone(s.revoked[since:]),
+	}
+	s.mu.Unlock()
+
+	signed, err := licensecrypto.SignDocument(claims, licensecrypto.RevocationListType, s.priv)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	writeTestJSON(w, dto.RevocationListResponse{Version: latest, List: signed})
+}
+
+func (s *fakeServer) jwks(w http.ResponseWriter, _ *http.Request) {
+	jwk, err := licensecrypto.PublicJWK(s.public())
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	writeTestJSON(w, licensecrypto.JWKSet{Keys: []licensecrypto.JWK{jwk}})
+}
+
+// testCacheKey is the cache key of test clients.
+var testCacheKey = []byte("0123456789abcdef")
+
+func writeTestJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestClient(t *testing.T, s *fakeServer, cfg Config) *Client {
+	t.Helper()
+
+	cfg.BaseURL = s.URL
+	if cfg.DeviceID == "" {
+		cfg.DeviceID = "device-1"
+	}
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestRefreshSendsFreshNonces(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}})
+
+	if _, err := c.Activate(ctx, "LIC-0000-0000"); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := c.Refresh(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.nonces) != 2 || s.nonces[0] == s.nonces[1] {
+		t.Fatalf("nonces = %q, want two distinct nonces", s.nonces)
+	}
+	for _, n := range s.nonces {
+		if raw, err := base64.RawURLEncoding.DecodeString(n); err != nil || len(raw) != 32 {
+			t.Errorf("nonce %q is not 32 base64url encoded bytes", n)
+		}
+	}
+	if c.claims.Nonce != s.nonces[1] {
+		t.Errorf("token echoes %q, want the last nonce %q", c.claims.Nonce, s.nonces[1])
+	}
+}
+
+func TestRefreshRejectsReplayedResponse(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}})
+
+	if _, err := c.Activate(ctx, "LIC-0000-0000"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// a fake server answering with an earlier response
+	s.mu.Lock()
+	s.replay = s.token("device-1", s.nonces[0], time.Hour)
+	s.mu.Unlock()
+	if _, err := c.Refresh(ctx); !errors.Is(err, ErrNonceMismatch) {
+		t.Errorf("Refresh() with a replayed response = %v, want %v", err, ErrNonceMismatch)
+	}
+}
+
+func TestActivationRequestSignsRebindProof(t *testing.T) {
+	s := newFakeServer(t)
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}, DeviceKey: priv})
+
+	a, err := c.activationRequest("LIC-0000-0000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.activationRequest("LIC-0000-0000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Nonce == "" || a.Nonce == b.Nonce {
+		t.Errorf("nonces = %q, %q, want two distinct nonces", a.Nonce, b.Nonce)
+	}
+	if err := licensecrypto.VerifyRebindProof(pub, a.Nonce, "device-1", pub, a.Proof); err != nil {
+		t.Errorf("VerifyRebindProof() = %v", err)
+	}
+}
+
+func TestProveSignsNonceAndToken(t *testing.T) {
+	s := newFakeServer(t)
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}, DeviceKey: priv})
+	c.token = "header.payload.signature"
+
+	ctx := context.Background()
+	var nonce, proof string
+	if err := c.prove(ctx, &nonce, &proof); err != nil {
+		t.Fatal(err)
+	}
+	if err := licensecrypto.VerifyDeviceProof(pub, nonce, c.token, proof); err != nil {
+		t.Errorf("VerifyDeviceProof() = %v", err)
+	}
+	s.mu.Lock()
+	issued := slices.Clone(s.issued)
+	s.mu.Unlock()
+	if !slices.Equal(issued, []string{nonce}) {
+		t.Errorf("proof signs nonce %q, want the one the server issued: %q", nonce, issued)
+	}
+
+	// without a device key the client picks the nonce and proves nothing
+	c = newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}})
+	nonce, proof = "", ""
+	if err := c.prove(ctx, &nonce, &proof); err != nil {
+		t.Fatal(err)
+	}
+	if nonce == "" || proof != "" {
+		t.Errorf("prove() = %q, %q, want a nonce and no proof", nonce, proof)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.issued) != 1 {
+		t.Errorf("unbound client fetched a server nonce")
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClockRollback(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}})
+
+	if _, err := c.Activate(ctx, "LIC-0000-0000"); err != nil {
+		t.Fatal(err)
+	}
+	seen := c.serverTime
+	if seen == 0 {
+		t.Fatal("activation did not record the server time")
+	}
+
+	// a clock a little behind the server's is tolerated
+	c.serverTime = time.Now().Add(time.Minute).UnixMilli()
+	if _, err := c.Token(ctx); err != nil {
+		t.Fatalf("Token() with a minute of skew = %v", err)
+	}
+
+	// a clock set back by an hour is not
+	c.serverTime = time.Now().Add(time.Hour).UnixMilli()
+	if _, err := c.Token(ctx); !errors.Is(err, ErrClockRollback) {
+		t.Fatalf("Token() after rollback = %v, want ErrClockRollback", err)
+	}
+	if got := c.State(); got != StateExpired {
+		t.Errorf("state = %v, want %v", got, StateExpired)
+	}
+}
+
+func TestServerTimeOnlyMovesForward(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}})
+
+	if _, err := c.Activate(ctx, "LIC-0000-0000"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a token older than the high-water mark, for instance a replayed one,
+	// must not lower it
+	later := time.Now().Add(time.Minute).UnixMilli()
+	c.serverTime = later
+	if _, err := c.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if c.serverTime != later {
+		t.Errorf("server time = %d, want it kept at %d", c.serverTime, later)
+	}
+}
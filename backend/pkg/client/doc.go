@@ -0,0 +1,5 @@
+// Package client is the Go client for clave. It activates licenses,
+// keeps the license token fresh and cached on disk, applies the server's
+// revocation list, and verifies tokens, signed license keys and license
+// files offline against pinned or fetched server keys.
+package client
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+func TestVerifyEncryptedToken(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := licensecrypto.EncryptToken(s.token("device-1", "", time.Hour), priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}, DecryptionKey: priv})
+	claims, err := c.Verify(ctx, enc)
+	if err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if claims.DeviceID != "device-1" || !claims.HasFeature("pro") {
+		t.Errorf("claims = %+v, want the encrypted token's", claims)
+	}
+
+	req, err := c.activationRequest("LIC-0000-0000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(priv.Public())
+	if req.DeviceEncryptionKey != base64.StdEncoding.EncodeToString(der) {
+		t.Error("activation does not register the decryption key's public half")
+	}
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, cfg := range map[string]Config{
+		"no key":    {Keys: []crypto.PublicKey{s.public()}},
+		"other key": {Keys: []crypto.PublicKey{s.public()}, DecryptionKey: other},
+	} {
+		if _, err := newTestClient(t, s, cfg).Verify(ctx, enc); err == nil {
+			t.Errorf("Verify() with %s succeeded", name)
+		}
+	}
+}
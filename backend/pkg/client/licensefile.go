@@ -0,0 +1,98 @@
+package client
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+var (
+	// ErrInvalidLicenseFile means a license file is malformed, signed with
+	// a key that is not the server's, or altered since it was signed.
+	ErrInvalidLicenseFile = errors.New("clave: invalid license file")
+	// ErrLicenseFileExpired means a license file has passed its expiry.
+	ErrLicenseFileExpired = errors.New("clave: license file has expired")
+	// ErrDeviceNotLicensed means a license file lists devices and this one
+	// is not among them.
+	ErrDeviceNotLicensed = errors.New("clave: device is not covered by the license file")
+)
+
+// LicenseFile is the content of a verified .lic file.
+type LicenseFile struct {
+	LicenseID      int32
+	ProductID      int32
+	ProductName    string
+	Features       []string
+	MaxActivations int32
+	IssuedAt       time.Time
+	// ExpiresAt is the zero time for perpetual licenses.
+	ExpiresAt time.Time
+	// DeviceIDs lists the devices the file is valid on; empty means any.
+	DeviceIDs []string
+	Metadata  map[string]string
+}
+
+// Check reports whether the file covers the device at the given time.
+func (f LicenseFile) Check(deviceID string, now time.Time) error {
+	if !f.ExpiresAt.IsZero() && now.After(f.ExpiresAt) {
+		return ErrLicenseFileExpired
+	}
+	if len(f.DeviceIDs) > 0 && !slices.Contains(f.DeviceIDs, deviceID) {
+		return ErrDeviceNotLicensed
+	}
+	return nil
+}
+
+// HasFeature reports whether the license grants feature.
+func (f LicenseFile) HasFeature(feature string) bool {
+	return slices.Contains(f.Features, feature)
+}
+
+// VerifyLicenseFile checks the signature of a JSON or PEM license file
+// against the server's public keys and returns its content. Expiry and
+// devices are left to LicenseFile.Check. Every verification failure is an
+// ErrInvalidLicenseFile.
+func VerifyLicenseFile(data []byte, keys ...crypto.PublicKey) (LicenseFile, error) {
+	lf, err := licensecrypto.VerifyLicenseFile(data, keys...)
+	if err != nil {
+		return LicenseFile{}, fmt.Errorf("%w: %v", ErrInvalidLicenseFile, err)
+	}
+
+	f := LicenseFile{
+		LicenseID:      lf.LicenseID,
+		ProductID:      lf.ProductID,
+		ProductName:    lf.ProductName,
+		Features:       lf.Features,
+		MaxActivations: lf.MaxActivations,
+		IssuedAt:       lf.IssuedAt,
+		DeviceIDs:      lf.HWIDs,
+		Metadata:       lf.Metadata,
+	}
+	if lf.ExpiresAt != nil {
+		f.ExpiresAt = *lf.ExpiresAt
+	}
+	return f, nil
+}
+
+// LoadLicenseFile reads a license file, verifies it and checks that it
+// covers the device now.
+func LoadLicenseFile(path, deviceID string, keys ...crypto.PublicKey) (LicenseFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LicenseFile{}, err
+	}
+
+	f, err := VerifyLicenseFile(data, keys...)
+	if err != nil {
+		return LicenseFile{}, err
+	}
+	if err := f.Check(deviceID, time.Now()); err != nil {
+		return f, err
+	}
+	return f, nil
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+func writeLicenseFile(t *testing.T, f licensecrypto.LicenseFile, priv ed25519.PrivateKey) string {
+	t.Helper()
+
+	key, err := licensecrypto.NewSigningKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := licensecrypto.SignLicenseFile(f, key, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "clave.lic")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLicenseFile(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		file licensecrypto.LicenseFile
+		hwid string
+		keys []crypto.PublicKey
+		want error
+	}{
+		{"valid", licensecrypto.LicenseFile{LicenseID: 1, ExpiresAt: &future, HWIDs: []string{"device-1"}}, "device-1", []crypto.PublicKey{pub}, nil},
+		{"perpetual", licensecrypto.LicenseFile{LicenseID: 1}, "device-1", []crypto.PublicKey{pub}, nil},
+		{"other server", licensecrypto.LicenseFile{LicenseID: 1}, "device-1", []crypto.PublicKey{otherPub}, ErrInvalidLicenseFile},
+		{"no keys", licensecrypto.LicenseFile{LicenseID: 1}, "device-1", nil, ErrInvalidLicenseFile},
+		{"expired", licensecrypto.LicenseFile{LicenseID: 1, ExpiresAt: &past}, "device-1", []crypto.PublicKey{pub}, ErrLicenseFileExpired},
+		{"other device", licensecrypto.LicenseFile{LicenseID: 1, HWIDs: []string{"device-2"}}, "device-1", []crypto.PublicKey{pub}, ErrDeviceNotLicensed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLicenseFile(t, tt.file, priv)
+
+			f, err := LoadLicenseFile(path, tt.hwid, tt.keys...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("LoadLicenseFile() = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && f.LicenseID != 1 {
+				t.Errorf("LicenseID = %d, want 1", f.LicenseID)
+			}
+		})
+	}
+}
+
+func TestVerifyLicenseFileTampered(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	path := writeLicenseFile(t, licensecrypto.LicenseFile{LicenseID: 1}, priv)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// change a character on the first line of the armored body
+	i := bytes.IndexByte(data, '\n') + 5
+	if data[i] == 'A' {
+		data[i] = 'B'
+	} else {
+		data[i] = 'A'
+	}
+	if _, err := VerifyLicenseFile(data, pub); !errors.Is(err, ErrInvalidLicenseFile) {
+		t.Errorf("VerifyLicenseFile() of a damaged file = %v, want %v", err, ErrInvalidLicenseFile)
+	}
+}
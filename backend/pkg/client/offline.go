@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+// ErrNoOfflineRequest means an offline activation response was imported
+// without a pending request from OfflineRequest.
+var ErrNoOfflineRequest = errors.New("clave: no offline activation request is pending")
+
+// OfflineRequest starts the activation of a machine that cannot reach the
+// server. The returned text is carried to a connected machine, for instance
+// as a file, and uploaded to the server's offline activation endpoint; the
+// response is imported with ActivateOffline. The request's nonce is kept in
+// the cache, if there is one, so the response can be imported after a
+// restart. Starting a new request invalidates earlier ones.
+func (c *Client) OfflineRequest(licenseKey string) (string, error) {
+	req, err := c.activationRequest(licenseKey)
+	if err != nil {
+		return "", err
+	}
+
+	blob, err := json.Marshal(offlineRequest{
+		LicenseKey: req.LicenseKey,
+		DeviceID:   req.DeviceID,
+		ProductID:  req.ProductID,
+		Nonce:      req.Nonce,
+
+		DevicePublicKey:     req.DevicePublicKey,
+		DeviceEncryptionKey: req.DeviceEncryptionKey,
+		Components:          req.Components,
+		Proof:               req.Proof,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.pendingNonce = req.Nonce
+	if err := c.save(); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(blob), nil
+}
+
+// ActivateOffline imports the server's response to the pending
+// OfflineRequest. The response has to be signed by the server, echo the
+// request's nonce and name this device; its token then becomes current.
+// Verifying it needs the server's keys, so air-gapped machines should pin
+// them in Config.Keys.
+func (c *Client) ActivateOffline(ctx context.Context, response string) (*Claims, error) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if c.pendingNonce == "" {
+		if err := c.load(ctx); err != nil && !errors.Is(err, ErrNotActivated) {
+			return nil, err
+		}
+	}
+	if c.pendingNonce == "" {
+		return nil, ErrNoOfflineRequest
+	}
+
+	if len(c.keys) == 0 {
+		if err := c.fetchKeys(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp licensecrypto.OfflineActivationClaims
+	if err := licensecrypto.VerifyDocument(strings.TrimSpace(response), licensecrypto.OfflineActivationType, &resp, c.keys); err != nil {
+		return nil, fmt.Errorf("clave: offline activation response: %v", err)
+	}
+	if resp.Nonce != c.pendingNonce {
+		return nil, ErrNonceMismatch
+	}
+	if resp.HWID != c.cfg.DeviceID {
+		return nil, ErrDeviceMismatch
+	}
+
+	nonce, activationID := c.pendingNonce, c.activationID
+	c.pendingNonce, c.activationID = "", resp.ActivationID
+	claims, err := c.accept(ctx, resp.Token, "")
+	if err != nil {
+		c.pendingNonce, c.activationID = nonce, activationID
+	}
+	return claimsOf(claims, err)
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/handlers/dto"
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// offlineResponse answers an offline request blob the way the server does.
+func (s *fakeServer) offlineResponse(t *testing.T, request string, edit func(*licensecrypto.OfflineActivationClaims)) string {
+	t.Helper()
+
+	raw, err := base64.RawURLEncoding.DecodeString(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blob dto.OfflineActivationBlob
+	if err := json.Unmarshal(raw, &blob); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	claims := &licensecrypto.OfflineActivationClaims{
+		Nonce:        blob.Nonce,
+		HWID:         blob.DeviceID,
+		ActivationID: 1,
+		Token:        s.token(blob.DeviceID, "", 365*24*time.Hour),
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(7 * 24 * time.Hour)),
+		},
+	}
+	if edit != nil {
+		edit(claims)
+	}
+
+	signed, err := licensecrypto.SignDocument(claims, licensecrypto.OfflineActivationType, s.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestOfflineActivation(t *testing.T) {
+	s := newFakeServer(t)
+	s.setDown(true)
+	ctx := context.Background()
+
+	cfg := Config{
+		ProductID:  1,
+		Keys:       []crypto.PublicKey{s.public()},
+		CachePath:  filepath.Join(t.TempDir(), "token"),
+		CacheKey:   testCacheKey,
+		Components: map[string]string{"cpu": "abc"},
+	}
+	c := newTestClient(t, s, cfg)
+
+	request, err := c.OfflineRequest("LIC-0000-0000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := base64.RawURLEncoding.DecodeString(request)
+	var blob dto.OfflineActivationBlob
+	if err := json.Unmarshal(raw, &blob); err != nil {
+		t.Fatal(err)
+	}
+	if blob.LicenseKey != "LIC-0000-0000" || blob.DeviceID != "device-1" || blob.ProductID != 1 || blob.Components["cpu"] != "abc" || len(blob.Nonce) < 16 {
+		t.Errorf("request blob = %+v", blob)
+	}
+
+	response := s.offlineResponse(t, request, nil)
+
+	// the machine restarts between request and response
+	c = newTestClient(t, s, cfg)
+	claims, err := c.ActivateOffline(ctx, response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(claims.ExpiresAt) < 300*24*time.Hour {
+		t.Errorf("offline token expires at %v, want a long-lived token", claims.ExpiresAt)
+	}
+	if c.State() != StateOnline {
+		t.Errorf("state = %v, want online", c.State())
+	}
+
+	// the token works without the server, also after another restart
+	c = newTestClient(t, s, cfg)
+	if _, err := c.Token(ctx); err != nil {
+		t.Errorf("Token() after offline activation: %v", err)
+	}
+
+	if _, err := c.ActivateOffline(ctx, response); !errors.Is(err, ErrNoOfflineRequest) {
+		t.Errorf("importing a response twice = %v, want %v", err, ErrNoOfflineRequest)
+	}
+}
+
+func TestOfflineActivationRejected(t *testing.T) {
+	s := newFakeServer(t)
+	other := newFakeServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		response func(c *Client, request string) string
+		want     error
+	}{
+		{
+			name: "other request",
+			response: func(c *Client, _ string) string {
+				older, _ := c.OfflineRequest("LIC-0000-0000")
+				resp := s.offlineResponse(t, older, nil)
+				_, _ = c.OfflineRequest("LIC-0000-0000")
+				return resp
+			},
+			want: ErrNonceMismatch,
+		},
+		{
+			name: "other device",
+			response: func(_ *Client, request string) string {
+				return s.offlineResponse(t, request, func(claims *licensecrypto.OfflineActivationClaims) {
+					claims.HWID = "device-2"
+				})
+			},
+			want: ErrDeviceMismatch,
+		},
+		{
+			name: "other server",
+			response: func(_ *Client, request string) string {
+				return other.offlineResponse(t, request, nil)
+			},
+		},
+		{
+			name: "expired response",
+			response: func(_ *Client, request string) string {
+				return s.offlineResponse(t, request, func(claims *licensecrypto.OfflineActivationClaims) {
+					claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				})
+			},
+		},
+		{
+			name: "license token",
+			response: func(_ *Client, _ string) string {
+				return s.token("device-1", "", time.Hour)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{s.public()}})
+			request, err := c.OfflineRequest("LIC-0000-0000")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.ActivateOffline(ctx, tt.response(c, request))
+			if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("ActivateOffline() = %v, want %v", err, tt.want)
+			}
+			if _, err := c.Claims(ctx); !errors.Is(err, ErrNotActivated) {
+				t.Errorf("rejected response activated the client: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Problem is an RFC 9457 problem response from the server. Use errors.As to
+// inspect the Type or Status of a failed call.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("clave: %s (%d): %s", p.Title, p.Status, p.Detail)
+	}
+	return fmt.Sprintf("clave: %s (%d)", p.Title, p.Status)
+}
+
+// Problem types the client reacts to.
+const (
+	ProblemActivationLimit    = "https://api.yourapp.dev/problems/activation-limit"
+	ProblemLicenseNotFound    = "https://api.yourapp.dev/problems/license-not-found"
+	ProblemLicenseRevoked     = "https://api.yourapp.dev/problems/license-revoked"
+	ProblemInvalidLicense     = "https://api.yourapp.dev/problems/invalid-license"
+	ProblemMalformedKey       = "https://api.yourapp.dev/problems/malformed-license-key"
+	ProblemNonceRequired      = "https://api.yourapp.dev/problems/nonce-required"
+	ProblemInvalidNonce       = "https://api.yourapp.dev/problems/invalid-nonce"
+	ProblemInvalidDeviceProof = "https://api.yourapp.dev/problems/invalid-device-proof"
+)
+
+// readProblem turns an error response into a *Problem, falling back to the
+// status line for bodies that are not problem documents.
+func readProblem(resp *http.Response) error {
+	p := &Problem{Status: resp.StatusCode}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err := json.Unmarshal(body, p); err != nil || p.Title == "" {
+		p.Title = http.StatusText(resp.StatusCode)
+	}
+	if p.Status == 0 {
+		p.Status = resp.StatusCode
+	}
+	return p
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+// ErrRevoked means the server's revocation list names the license,
+// activation or token of this device.
+var ErrRevoked = errors.New("clave: license has been revoked")
+
+// CheckRevocations fetches the revocation list entries published since the
+// last check and drops the current token if they name it. Refreshing a
+// token already tells the client about revocations, but long-lived tokens
+// such as those of offline activations are rarely refreshed; hosts should
+// call this whenever the server is reachable.
+func (c *Client) CheckRevocations(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if err := c.load(ctx); err != nil {
+		return err
+	}
+
+	since := c.revocationVersion
+	var resp revocationListResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/revocations?since="+strconv.FormatInt(since, 10), nil, &resp); err != nil {
+		return err
+	}
+
+	var list licensecrypto.RevocationListClaims
+	err := licensecrypto.VerifyDocument(resp.List, licensecrypto.RevocationListType, &list, c.keys)
+	if errors.Is(err, licensecrypto.ErrUnknownKey) && len(c.cfg.Keys) == 0 {
+		if err := c.fetchKeys(ctx); err != nil {
+			return err
+		}
+		err = licensecrypto.VerifyDocument(resp.List, licensecrypto.RevocationListType, &list, c.keys)
+	}
+	if err != nil {
+		return fmt.Errorf("clave: revocation list: %v", err)
+	}
+	// the server answers a version it does not know, e.g. after a restore,
+	// with the full list
+	if list.Since != since && list.Since != 0 {
+		return fmt.Errorf("clave: revocation list: got entries since version %d, asked for %d", list.Since, since)
+	}
+
+	for _, e := range list.Revocations {
+		if c.revokes(e) {
+			c.drop()
+			c.setState(StateExpired)
+			return ErrRevoked
+		}
+	}
+
+	c.revocationVersion = list.Version
+	return c.save()
+}
+
+// revokes reports whether e names the current token, its activation or its
+// license.
+func (c *Client) revokes(e licensecrypto.RevocationEntry) bool {
+	switch e.Kind {
+	case licensecrypto.RevokedLicense:
+		return c.claims.Subject == "lic_"+e.ID
+	case licensecrypto.RevokedActivation:
+		return c.activationID != 0 && e.ID == strconv.Itoa(int(c.activationID))
+	case licensecrypto.RevokedToken:
+		return c.claims.ID != "" && e.ID == c.claims.ID
+	}
+	return false
+}
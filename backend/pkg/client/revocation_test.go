@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+func TestCheckRevocations(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		kind    string
+		id      func(c *Client) string
+		revoked bool
+	}{
+		{"license", licensecrypto.RevokedLicense, func(*Client) string { return "1" }, true},
+		{"other license", licensecrypto.RevokedLicense, func(*Client) string { return "2" }, false},
+		{"activation", licensecrypto.RevokedActivation, func(*Client) string { return "1" }, true},
+		{"other activation", licensecrypto.RevokedActivation, func(*Client) string { return "7" }, false},
+		{"token", licensecrypto.RevokedToken, func(c *Client) string { return c.claims.ID }, true},
+		{"other token", licensecrypto.RevokedToken, func(*Client) string { return "AAAAAAAAAAAAAAAAAAAAAA" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeServer(t)
+			s.revoke(licensecrypto.RevokedLicense, "3")
+
+			cfg := Config{Keys: []crypto.PublicKey{s.public()}, CachePath: filepath.Join(t.TempDir(), "token"), CacheKey: testCacheKey}
+			c := newTestClient(t, s, cfg)
+			if _, err := c.Activate(ctx, "LIC-0000-0000"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.CheckRevocations(ctx); err != nil {
+				t.Fatalf("CheckRevocations() = %v", err)
+			}
+
+			s.revoke(tt.kind, tt.id(c))
+
+			// the version and activation survive a restart
+			c = newTestClient(t, s, cfg)
+			err := c.CheckRevocations(ctx)
+			if tt.revoked {
+				if !errors.Is(err, ErrRevoked) {
+					t.Fatalf("CheckRevocations() = %v, want %v", err, ErrRevoked)
+				}
+				if _, err := c.Token(ctx); !errors.Is(err, ErrNotActivated) {
+					t.Errorf("Token() after revocation = %v, want %v", err, ErrNotActivated)
+				}
+				if c.State() != StateExpired {
+					t.Errorf("state = %v, want expired", c.State())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckRevocations() = %v", err)
+			}
+			if c.revocationVersion != 2 {
+				t.Errorf("revocation version = %d, want 2", c.revocationVersion)
+			}
+		})
+	}
+}
+
+func TestCheckRevocationsRejectsForgedList(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	other := newFakeServer(t)
+
+	c := newTestClient(t, s, Config{Keys: []crypto.PublicKey{other.public()}})
+	c.token, c.claims = "token", &licensecrypto.LicenseClaims{}
+
+	if err := c.CheckRevocations(ctx); err == nil {
+		t.Fatal("accepted a revocation list signed by another key")
+	}
+	if c.token == "" {
+		t.Error("forged list dropped the token")
+	}
+}
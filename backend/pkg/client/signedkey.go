@@ -0,0 +1,61 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+var (
+	// ErrMalformedKey means the input cannot be a license key.
+	ErrMalformedKey = errors.New("clave: malformed license key")
+	// ErrKeyChecksum means the key's check group does not match, which
+	// almost always means it was mistyped.
+	ErrKeyChecksum = errors.New("clave: license key checksum mismatch")
+	// ErrInvalidSignature means a signed license key was not signed by any
+	// of the given keys, or has been altered.
+	ErrInvalidSignature = errors.New("clave: license key signature is invalid")
+	// ErrKeyExpired means a signed license key has passed its expiry.
+	ErrKeyExpired = errors.New("clave: license key has expired")
+)
+
+// SignedKey is the content of a signed license key.
+type SignedKey struct {
+	ProductID int32
+	// ExpiresAt is the zero time for perpetual licenses.
+	ExpiresAt time.Time
+	Features  []string
+}
+
+// VerifySignedKey checks a signed license key offline against the server's
+// Ed25519 public keys and returns its content. An expired key is returned
+// along with ErrKeyExpired, so the host can tell it apart from a forged one.
+func VerifySignedKey(key string, keys ...crypto.PublicKey) (SignedKey, error) {
+	err := ErrInvalidSignature
+	for _, k := range keys {
+		pub, ok := k.(ed25519.PublicKey)
+		if !ok {
+			continue
+		}
+
+		content, verr := licensecrypto.VerifySignedKey(key, pub)
+		switch {
+		case errors.Is(verr, licensecrypto.ErrInvalidSignature):
+			continue
+		case errors.Is(verr, licensecrypto.ErrKeyChecksum):
+			return SignedKey{}, ErrKeyChecksum
+		case verr != nil:
+			return SignedKey{}, ErrMalformedKey
+		}
+
+		sk := SignedKey{ProductID: content.ProductID, ExpiresAt: content.ExpiresAt, Features: content.Features}
+		if !sk.ExpiresAt.IsZero() && !time.Now().Before(sk.ExpiresAt) {
+			return sk, ErrKeyExpired
+		}
+		return sk, nil
+	}
+	return SignedKey{}, err
+}
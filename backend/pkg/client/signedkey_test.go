@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheetahbyte/clave/internal/licensecrypto"
+)
+
+func TestVerifySignedKey(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	ec, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	issue := func(exp time.Time) string {
+		t.Helper()
+		key, err := licensecrypto.IssueSignedKey(licensecrypto.DefaultKeySpec, licensecrypto.SignedKey{
+			ProductID: 3,
+			ExpiresAt: exp,
+			Features:  []string{"pro"},
+		}, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	valid := issue(time.Now().Add(time.Hour).Truncate(time.Second))
+	expired := issue(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	tests := []struct {
+		name string
+		key  string
+		keys []crypto.PublicKey
+		want error
+	}{
+		{"valid", valid, []crypto.PublicKey{pub}, nil},
+		{"rotated keys", strings.ToLower(valid), []crypto.PublicKey{ec.Public(), otherPub, pub}, nil},
+		{"expired", expired, []crypto.PublicKey{pub}, ErrKeyExpired},
+		{"unknown key", valid, []crypto.PublicKey{otherPub}, ErrInvalidSignature},
+		{"no keys", valid, nil, ErrInvalidSignature},
+		{"not a key", "hello", []crypto.PublicKey{pub}, ErrKeyChecksum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := VerifySignedKey(tt.key, tt.keys...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("VerifySignedKey() = %v, want %v", err, tt.want)
+			}
+			if (err == nil || errors.Is(err, ErrKeyExpired)) && (k.ProductID != 3 || len(k.Features) != 1) {
+				t.Errorf("VerifySignedKey() = %+v", k)
+			}
+		})
+	}
+}
+
+// The SDK's errors are its own, so callers cannot come to depend on the
+// server's internal error values through errors.Is.
+func TestErrorsDoNotWrapInternals(t *testing.T) {
+	s := newFakeServer(t)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+
+	_, tokenErr := newTestClient(t, s, Config{Keys: []crypto.PublicKey{otherPub}}).
+		Verify(context.Background(), s.token("device-1", "", time.Hour))
+	if !errors.Is(tokenErr, ErrInvalidToken) {
+		t.Errorf("Verify() with an unknown key = %v, want %v", tokenErr, ErrInvalidToken)
+	}
+	_, checksumErr := VerifySignedKey("hello", otherPub)
+	signed, err := licensecrypto.IssueSignedKey(licensecrypto.DefaultKeySpec, licensecrypto.SignedKey{ProductID: 1}, s.priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, signatureErr := VerifySignedKey(signed, otherPub)
+	_, fileErr := VerifyLicenseFile([]byte("not a license file"), otherPub)
+
+	internal := []error{
+		licensecrypto.ErrMalformedKey,
+		licensecrypto.ErrKeyChecksum,
+		licensecrypto.ErrInvalidSignature,
+		licensecrypto.ErrUnknownKey,
+	}
+	for _, err := range []error{tokenErr, checksumErr, signatureErr, fileErr} {
+		if err == nil {
+			t.Fatal("verification succeeded")
+		}
+		for _, ierr := range internal {
+			if errors.Is(err, ierr) {
+				t.Errorf("%v wraps internal error %v", err, ierr)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import "errors"
+
+// State is the license state of the device as the host application sees
+// it.
+type State int
+
+const (
+	// StateUnknown is the state before the first token is loaded.
+	StateUnknown State = iota
+	// StateOnline means the current token is within its lifetime and the
+	// server confirmed it when it was last asked.
+	StateOnline
+	// StateOffline means the current token is within its lifetime, but the
+	// last attempt to refresh it did not reach the server.
+	StateOffline
+	// StateGrace means the token has expired and could not be refreshed,
+	// but is still inside the configured offline grace window.
+	StateGrace
+	// StateExpired means there is no usable token: the grace window has
+	// passed, the server refused the token or the clock was set back.
+	StateExpired
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOnline:
+		return "online"
+	case StateOffline:
+		return "offline"
+	case StateGrace:
+		return "grace"
+	case StateExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	// ErrTokenExpired means the token and its grace window have run out
+	// without the server being reachable.
+	ErrTokenExpired = errors.New("clave: license token has expired")
+	// ErrClockRollback means the local clock is behind the last signed
+	// server time seen, which is how clock tampering shows.
+	ErrClockRollback = errors.New("clave: system clock is behind the last seen server time")
+)
+
+type stateChange struct {
+	from, to State
+}
+
+// setState records a state change. Callers hold c.mu; the host is told in
+// unlock, so its callback may call back into the client.
+func (c *Client) setState(s State) {
+	if s == c.state {
+		return
+	}
+	c.changes = append(c.changes, stateChange{from: c.state, to: s})
+	c.state = s
+}
+
+// unlock releases c.mu and then reports the state changes made while it
+// was held.
+func (c *Client) unlock() {
+	changes := c.changes
+	c.changes = nil
+	c.mu.Unlock()
+
+	if c.cfg.OnStateChange == nil {
+		return
+	}
+	for _, ch := range changes {
+		c.cfg.OnStateChange(ch.from, ch.to)
+	}
+}
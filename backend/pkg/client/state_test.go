@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenStates(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeServer(t)
+	s.ttl = 2 * time.Second
+
+	var mu sync.Mutex
+	var changes []string
+	c := newTestClient(t, s, Config{
+		Keys:          []crypto.PublicKey{s.public()},
+		RefreshBefore: time.Hour,
+		OfflineGrace:  2 * time.Second,
+		OnStateChange: func(from, to State) {
+			mu.Lock()
+			changes = append(changes, from.String()+">"+to.String())
+			mu.Unlock()
+		},
+	})
+
+	if _, err := c.Activate(ctx, "LIC-0000-0000"); err != nil {
+		t.Fatal(err)
+	}
+	check := func(want State) {
+		t.Helper()
+		if _, err := c.Token(ctx); err != nil && want != StateExpired {
+			t.Fatalf("Token() = %v", err)
+		}
+		if got := c.State(); got != want {
+			t.Errorf("state = %v, want %v", got, want)
+		}
+	}
+
+	// every call is within RefreshBefore, so each one refreshes
+	check(StateOnline)
+
+	s.setDown(true)
+	check(StateOffline)
+
+	time.Sleep(2 * time.Second)
+	check(StateGrace)
+
+	time.Sleep(2 * time.Second)
+	if _, err := c.Token(ctx); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Token() after the grace window = %v, want %v", err, ErrTokenExpired)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"unknown>online", "online>offline", "offline>grace", "grace>expired"}
+	if !slices.Equal(changes, want) {
+		t.Errorf("state changes = %q, want %q", changes, want)
+	}
+}
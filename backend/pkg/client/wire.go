@@ -0,0 +1,63 @@
+package client
+
+// The request and response bodies of the server's device API. They are
+// kept here rather than shared with the server so that the package's wire
+// format only changes on purpose.
+
+type activateRequest struct {
+	LicenseKey          string            `json:"licenseKey"`
+	DeviceID            string            `json:"deviceId"`
+	ProductID           int32             `json:"productId"`
+	DevicePublicKey     string            `json:"devicePublicKey,omitempty"`
+	DeviceEncryptionKey string            `json:"deviceEncryptionKey,omitempty"`
+	Components          map[string]string `json:"components,omitempty"`
+	Nonce               string            `json:"nonce,omitempty"`
+	Proof               string            `json:"proof,omitempty"`
+}
+
+type activateResponse struct {
+	ActivationID int32  `json:"activationId"`
+	Token        string `json:"token"`
+}
+
+// offlineRequest is the activation request file of an air-gapped machine,
+// base64url encoded.
+type offlineRequest struct {
+	LicenseKey string `json:"licenseKey"`
+	DeviceID   string `json:"deviceId"`
+	ProductID  int32  `json:"productId,omitempty"`
+	Nonce      string `json:"nonce"`
+
+	DevicePublicKey     string            `json:"devicePublicKey,omitempty"`
+	DeviceEncryptionKey string            `json:"deviceEncryptionKey,omitempty"`
+	Components          map[string]string `json:"components,omitempty"`
+	Proof               string            `json:"proof,omitempty"`
+}
+
+type validationRequest struct {
+	Token      string            `json:"token"`
+	DeviceID   string            `json:"deviceId"`
+	Nonce      string            `json:"nonce,omitempty"`
+	Proof      string            `json:"proof,omitempty"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+type validationResponse struct {
+	Token string `json:"token"`
+}
+
+type nonceResponse struct {
+	Nonce string `json:"nonce"`
+}
+
+type heartbeatRequest struct {
+	Token    string `json:"token"`
+	DeviceID string `json:"deviceId"`
+	Nonce    string `json:"nonce,omitempty"`
+	Proof    string `json:"proof,omitempty"`
+}
+
+type revocationListResponse struct {
+	Version int64  `json:"version"`
+	List    string `json:"list"`
+}
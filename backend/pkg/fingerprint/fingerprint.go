@@ -0,0 +1,205 @@
+// Package fingerprint derives a stable device id for activation from
+// hardware and OS identifiers of a Linux machine. Raw identifiers never
+// leave the machine: every component is hashed with an application salt,
+// so ids cannot be correlated across vendors. By default only identifiers
+// every user can read are used, so a program gets the same id whether or
+// not it runs as root; the root-only SMBIOS system UUID is opt-in.
+package fingerprint
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Component names, as used in Fingerprint.Components.
+const (
+	MachineID   = "machine_id"
+	ProductUUID = "product_uuid"
+	MAC         = "mac"
+	DiskSerial  = "disk_serial"
+)
+
+var ErrNoComponents = errors.New("fingerprint: no hardware identifiers found")
+
+// Fingerprint is a salted device fingerprint.
+type Fingerprint struct {
+	// ID combines all components and is meant for the deviceId of an
+	// activation.
+	ID string
+	// Components maps component names to their individual hashes, so a
+	// server can tell which part of a machine changed.
+	Components map[string]string
+}
+
+// Reader reads identifiers below a root file system. The zero value reads
+// the running machine; tests point FS at a fixture tree laid out like /.
+type Reader struct {
+	FS fs.FS
+	// ProductUUID adds the SMBIOS system UUID as a component. Only root
+	// can read it, so set it only for programs that always run as root;
+	// otherwise the id would change with the user running the program.
+	ProductUUID bool
+}
+
+// Generate fingerprints the running machine.
+func Generate(salt []byte) (Fingerprint, error) {
+	return Reader{}.Generate(salt)
+}
+
+// Generate reads all components that are available and hashes them with
+// salt. Components the machine does not have are left out. The SMBIOS
+// system UUID is read only if r.ProductUUID is set.
+func (r Reader) Generate(salt []byte) (Fingerprint, error) {
+	fsys := r.FS
+	if fsys == nil {
+		fsys = os.DirFS("/")
+	}
+
+	type source struct {
+		name string
+		read func(fs.FS) string
+	}
+	sources := []source{
+		{MachineID, machineID},
+		{MAC, primaryMAC},
+		{DiskSerial, diskSerial},
+	}
+	if r.ProductUUID {
+		sources = append(sources, source{ProductUUID, productUUID})
+	}
+
+	f := Fingerprint{Components: map[string]string{}}
+	var names []string
+	for _, s := range sources {
+		v := s.read(fsys)
+		if v == "" {
+			continue
+		}
+		f.Components[s.name] = hash(salt, s.name, v)
+		names = append(names, s.name)
+	}
+	if len(names) == 0 {
+		return Fingerprint{}, ErrNoComponents
+	}
+
+	var all strings.Builder
+	for _, n := range names {
+		all.WriteString(n + "=" + f.Components[n] + "\n")
+	}
+	f.ID = hash(salt, "id", all.String())
+	return f, nil
+}
+
+func hash(salt []byte, name, value string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte("clave-fingerprint\n" + name + "\n" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func readTrimmed(fsys fs.FS, name string) string {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func machineID(fsys fs.FS) string {
+	for _, p := range []string{"etc/machine-id", "var/lib/dbus/machine-id"} {
+		if v := readTrimmed(fsys, p); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// productUUID is the SMBIOS system UUID. Some firmware ships placeholder
+// values, which identify nothing.
+func productUUID(fsys fs.FS) string {
+	v := strings.ToLower(readTrimmed(fsys, "sys/class/dmi/id/product_uuid"))
+	switch v {
+	case "", "00000000-0000-0000-0000-000000000000", "ffffffff-ffff-ffff-ffff-ffffffffffff",
+		"03000200-0400-0500-0006-000700080009":
+		return ""
+	}
+	return v
+}
+
+// primaryMAC is the address of the first physical interface by name.
+// Virtual interfaces (bridges, veth, VPNs) have no device link and come and
+// go, so they are skipped.
+func primaryMAC(fsys fs.FS) string {
+	entries, err := fs.ReadDir(fsys, "sys/class/net")
+	if err != nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+
+	for _, n := range names {
+		dir := path.Join("sys/class/net", n)
+		if _, err := fs.Stat(fsys, path.Join(dir, "device")); err != nil {
+			continue
+		}
+		mac := strings.ToLower(readTrimmed(fsys, path.Join(dir, "address")))
+		if mac == "" || mac == "00:00:00:00:00:00" {
+			continue
+		}
+		return mac
+	}
+	return ""
+}
+
+// diskSerial is the serial number of the first physical disk by name.
+func diskSerial(fsys fs.FS) string {
+	entries, err := fs.ReadDir(fsys, "sys/block")
+	if err != nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		n := e.Name()
+		if virtualDisk(n) {
+			continue
+		}
+		names = append(names, n)
+	}
+	slices.Sort(names)
+
+	for _, n := range names {
+		dev := path.Join("sys/block", n, "device")
+		if v := readTrimmed(fsys, path.Join(dev, "serial")); v != "" {
+			return v
+		}
+		// SCSI and SATA disks expose the unit serial number VPD page:
+		// a four byte header followed by the serial.
+		if b, err := fs.ReadFile(fsys, path.Join(dev, "vpd_pg80")); err == nil && len(b) > 4 {
+			if v := string(bytes.TrimSpace(bytes.Trim(b[4:], "\x00"))); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func virtualDisk(name string) bool {
+	for _, p := range []string{"loop", "ram", "zram", "dm-", "md", "sr", "nbd", "fd"} {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
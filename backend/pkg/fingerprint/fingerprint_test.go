@@ -0,0 +1,237 @@
+package fingerprint
+
+import (
+	"errors"
+	"io/fs"
+	"maps"
+	"testing"
+	"testing/fstest"
+)
+
+var testSalt = []byte("test-salt")
+
+func dir() *fstest.MapFile { return &fstest.MapFile{Mode: fs.ModeDir | 0o755} }
+
+func file(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+// machine is a fixture laid out like / on a typical server.
+func machine() fstest.MapFS {
+	return fstest.MapFS{
+		"etc/machine-id": file("4c4c4544004e3510804bb4c04f4b3732\n"),
+
+		"sys/class/net/lo/address":        file("00:00:00:00:00:00\n"),
+		"sys/class/net/docker0/address":   file("02:42:ac:11:00:01\n"),
+		"sys/class/net/enp3s0/address":    file("3C:7C:3F:1E:A0:42\n"),
+		"sys/class/net/enp3s0/device":     dir(),
+		"sys/class/net/wlp4s0/address":    file("a4:c3:f0:85:11:7e\n"),
+		"sys/class/net/wlp4s0/device":     dir(),
+		"sys/block/loop0/device/serial":   file("loop\n"),
+		"sys/block/nvme0n1/device/serial": file("  S4EWNX0R123456  \n"),
+	}
+}
+
+func generate(t *testing.T, fsys fs.FS) Fingerprint {
+	t.Helper()
+	f, err := Reader{FS: fsys}.Generate(testSalt)
+	if err != nil {
+		t.Fatalf("Generate() = %v", err)
+	}
+	return f
+}
+
+func TestGenerate(t *testing.T) {
+	f := generate(t, machine())
+
+	want := map[string]string{
+		MachineID:  hash(testSalt, MachineID, "4c4c4544004e3510804bb4c04f4b3732"),
+		MAC:        hash(testSalt, MAC, "3c:7c:3f:1e:a0:42"),
+		DiskSerial: hash(testSalt, DiskSerial, "S4EWNX0R123456"),
+	}
+	if !maps.Equal(f.Components, want) {
+		t.Errorf("components = %v, want %v", f.Components, want)
+	}
+	if f.ID == "" {
+		t.Fatal("empty id")
+	}
+	if again := generate(t, machine()); again.ID != f.ID {
+		t.Errorf("id changed between runs: %q, %q", f.ID, again.ID)
+	}
+}
+
+// The DMI system UUID is only readable by root; the id must not depend on
+// whether the caller can read it.
+func TestGenerateIgnoresRootOnlySources(t *testing.T) {
+	user := generate(t, machine())
+
+	m := machine()
+	m["sys/class/dmi/id/product_uuid"] = file("4c4c4544-004e-3510-804b-b4c04f4b3732\n")
+	root := generate(t, m)
+
+	if root.ID != user.ID {
+		t.Errorf("id differs with product_uuid readable: %q, %q", root.ID, user.ID)
+	}
+	if !maps.Equal(root.Components, user.Components) {
+		t.Errorf("components differ with product_uuid readable: %v, %v", root.Components, user.Components)
+	}
+}
+
+func TestGenerateProductUUID(t *testing.T) {
+	user := generate(t, machine())
+
+	m := machine()
+	m["sys/class/dmi/id/product_uuid"] = file("4C4C4544-004E-3510-804B-B4C04F4B3732\n")
+	f, err := Reader{FS: m, ProductUUID: true}.Generate(testSalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := maps.Clone(user.Components)
+	want[ProductUUID] = hash(testSalt, ProductUUID, "4c4c4544-004e-3510-804b-b4c04f4b3732")
+	if !maps.Equal(f.Components, want) {
+		t.Errorf("components = %v, want %v", f.Components, want)
+	}
+	if f.ID == user.ID {
+		t.Error("id unchanged with product_uuid")
+	}
+}
+
+// Without a usable product UUID, unreadable for non-root users or a
+// firmware placeholder, the opt-in changes nothing.
+func TestGenerateProductUUIDUnavailable(t *testing.T) {
+	user := generate(t, machine())
+
+	placeholder := machine()
+	placeholder["sys/class/dmi/id/product_uuid"] = file("03000200-0400-0500-0006-000700080009\n")
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing":     machine(),
+		"placeholder": placeholder,
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, err := Reader{FS: fsys, ProductUUID: true}.Generate(testSalt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.ID != user.ID || !maps.Equal(f.Components, user.Components) {
+				t.Errorf("fingerprint = %v, want %v", f, user)
+			}
+		})
+	}
+}
+
+func TestGenerateSalt(t *testing.T) {
+	a, err := Reader{FS: machine()}.Generate([]byte("vendor-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Reader{FS: machine()}.Generate([]byte("vendor-b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.ID == b.ID {
+		t.Error("ids of different salts match")
+	}
+	for name := range a.Components {
+		if a.Components[name] == b.Components[name] {
+			t.Errorf("%s hashes of different salts match", name)
+		}
+	}
+}
+
+func TestGenerateComponentChangesID(t *testing.T) {
+	before := generate(t, machine())
+
+	m := machine()
+	m["sys/block/nvme0n1/device/serial"] = file("S4EWNX0R999999\n")
+	after := generate(t, m)
+
+	if after.ID == before.ID {
+		t.Error("id unchanged after disk swap")
+	}
+	if after.Components[MachineID] != before.Components[MachineID] || after.Components[MAC] != before.Components[MAC] {
+		t.Error("unchanged components hash differently")
+	}
+	if after.Components[DiskSerial] == before.Components[DiskSerial] {
+		t.Error("disk serial hash unchanged")
+	}
+}
+
+func TestMachineIDFallback(t *testing.T) {
+	got := machineID(fstest.MapFS{
+		"etc/machine-id":          file("\n"),
+		"var/lib/dbus/machine-id": file("dbus-id\n"),
+	})
+	if got != "dbus-id" {
+		t.Errorf("machineID = %q, want dbus-id", got)
+	}
+}
+
+func TestPrimaryMAC(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"first physical by name", machine(), "3c:7c:3f:1e:a0:42"},
+		{"virtual only", fstest.MapFS{
+			"sys/class/net/lo/address":    file("00:00:00:00:00:00\n"),
+			"sys/class/net/veth1/address": file("b2:11:22:33:44:55\n"),
+		}, ""},
+		{"zero address", fstest.MapFS{
+			"sys/class/net/eth0/address": file("00:00:00:00:00:00\n"),
+			"sys/class/net/eth0/device":  dir(),
+			"sys/class/net/eth1/address": file("52:54:00:12:34:56\n"),
+			"sys/class/net/eth1/device":  dir(),
+		}, "52:54:00:12:34:56"},
+		{"no sysfs", fstest.MapFS{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := primaryMAC(tt.fsys); got != tt.want {
+				t.Errorf("primaryMAC = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiskSerial(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"nvme serial", machine(), "S4EWNX0R123456"},
+		{"vpd page", fstest.MapFS{
+			"sys/block/sda/device/vpd_pg80": file("\x00\x80\x00\x14  WD-WCC4N1234567\x00\x00"),
+		}, "WD-WCC4N1234567"},
+		{"serial before vpd", fstest.MapFS{
+			"sys/block/sda/device/serial":   file("SERIAL\n"),
+			"sys/block/sda/device/vpd_pg80": file("\x00\x80\x00\x03VPD"),
+		}, "SERIAL"},
+		{"header only vpd", fstest.MapFS{
+			"sys/block/sda/device/vpd_pg80": file("\x00\x80\x00\x00"),
+			"sys/block/sdb/device/serial":   file("SECOND\n"),
+		}, "SECOND"},
+		{"virtual disks skipped", fstest.MapFS{
+			"sys/block/dm-0/device/serial":  file("dm\n"),
+			"sys/block/zram0/device/serial": file("zram\n"),
+			"sys/block/sr0/device/serial":   file("cdrom\n"),
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diskSerial(tt.fsys); got != tt.want {
+				t.Errorf("diskSerial = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateNoComponents(t *testing.T) {
+	_, err := Reader{FS: fstest.MapFS{
+		"sys/class/dmi/id/product_uuid": file("4c4c4544-004e-3510-804b-b4c04f4b3732\n"),
+	}}.Generate(testSalt)
+	if !errors.Is(err, ErrNoComponents) {
+		t.Errorf("Generate() = %v, want ErrNoComponents", err)
+	}
+}
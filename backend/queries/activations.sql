@@ -2,7 +2,31 @@
 select * from activations where license_id = $1;
 
 -- name: ActivateLicense :one
-insert into activations (license_id, hwid) values($1, $2) returning id;
+insert into activations (license_id, hwid, mode, device_public_key, device_encryption_key, components) values($1, $2, $3, $4, $5, $6) returning id;
 
 -- name: CountActivations :one
 select count(*) from activations where license_id = $1;
+
+-- name: GetActivationByHwid :one
+select * from activations where license_id = $1 and hwid = $2;
+
+-- name: DeleteActivation :one
+delete from activations where id = $1 returning *;
+
+-- name: TouchActivation :exec
+update activations set last_check_in = now() where id = $1;
+
+-- name: UpdateActivationFingerprint :exec
+update activations set
+    hwid = $2,
+    components = $3,
+    device_public_key = coalesce(sqlc.narg(device_public_key), device_public_key),
+    device_encryption_key = coalesce(sqlc.narg(device_encryption_key), device_encryption_key),
+    last_check_in = now()
+where id = $1;
+
+-- name: RecordActivationDrift :exec
+insert into activation_drift (activation_id, old_hwid, new_hwid, matched, total, changed) values($1, $2, $3, $4, $5, $6);
+
+-- name: ListActivationDrift :many
+select * from activation_drift where activation_id = $1 order by id;
@@ -0,0 +1,5 @@
+-- name: CreateLicenseBatch :one
+insert into license_batches (product_id, size) values($1, $2) returning *;
+
+-- name: GetLicenseBatchById :one
+select * from license_batches where id = $1;
@@ -6,3 +6,27 @@ select * from licenses where lookup_digest = $1;
 
 -- name: CreateLicense :one
 INSERT INTO licenses(product_id, max_activations, lookup_digest, key_phc) values($1, $2, $3, $4) returning *;
+
+-- name: CreateLicenses :copyfrom
+INSERT INTO licenses(product_id, max_activations, lookup_digest, key_phc, batch_id) values($1, $2, $3, $4, $5);
+
+-- name: ImportLicense :one
+INSERT INTO licenses(product_id, max_activations, expires_at, lookup_digest, key_phc) values($1, $2, $3, $4, $5) returning *;
+
+-- name: GetProductLicenseByDigest :one
+select * from licenses where lookup_digest = $1 and product_id = $2;
+
+-- name: CreateSignedLicense :one
+INSERT INTO licenses(product_id, max_activations, expires_at, features, key_type, lookup_digest, key_phc) values($1, $2, $3, $4, 'signed', $5, $6) returning *;
+
+-- name: DeactivateLicense :one
+update licenses set is_active = false where id = $1 returning *;
+
+-- name: ListLicenses :many
+select * from licenses
+where sqlc.narg(product_id)::integer is null or product_id = sqlc.narg(product_id)::integer
+order by id desc
+limit sqlc.arg(row_limit);
+
+-- name: UpdateLicenseKeyHash :exec
+update licenses set key_phc = $2, key_normalized = true where id = $1;
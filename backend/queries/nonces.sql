@@ -0,0 +1,6 @@
+-- name: ConsumeNonce :execrows
+insert into used_nonces (nonce, expires_at) values($1, $2)
+on conflict (nonce) do nothing;
+
+-- name: DeleteExpiredNonces :exec
+delete from used_nonces where expires_at < now();
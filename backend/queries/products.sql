@@ -3,3 +3,10 @@ select * from products;
 
 -- name: GetOneById :one
 select * from products where id = $1;
+
+-- name: CreateProduct :one
+insert into products (name, version, key_prefix, key_bytes, key_group_size, require_validation_nonce, token_format, signing_alg, token_encryption_key, hwid_match_threshold, imported_key_prefixes) values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) returning *;
+
+-- name: GetProductKeyPrefixes :many
+select id, key_prefix, imported_key_prefixes from products
+where key_prefix is not null or cardinality(imported_key_prefixes) > 0;
@@ -0,0 +1,16 @@
+-- name: NextRevocationVersion :one
+update revocation_counter set version = version + 1 returning version;
+
+-- name: RecordRevocation :one
+insert into revocations (kind, subject, version) values($1, $2, $3)
+on conflict (kind, subject) do update set revoked_at = revocations.revoked_at
+returning *;
+
+-- name: ListRevocationsSince :many
+select * from revocations where version > $1 order by version;
+
+-- name: LatestRevocationVersion :one
+select version from revocation_counter;
+
+-- name: IsRevoked :one
+select exists(select 1 from revocations where kind = $1 and subject = $2);
@@ -0,0 +1,8 @@
+-- name: RecordIssuedToken :exec
+insert into issued_tokens (jti, license_id, expires_at) values($1, $2, $3);
+
+-- name: IssuedTokenExists :one
+select exists(select 1 from issued_tokens where jti = $1);
+
+-- name: DeleteExpiredIssuedTokens :exec
+delete from issued_tokens where expires_at < now();